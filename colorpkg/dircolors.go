@@ -0,0 +1,231 @@
+package colorpkg
+
+import (
+	"ls/utils"
+	"strings"
+)
+
+// databaseKeys maps the keywords of a dircolors database to the two-letter
+// keys used in LS_COLORS.
+var databaseKeys = map[string]string{
+	"NORMAL":                "no",
+	"NORM":                  "no",
+	"FILE":                  "fi",
+	"RESET":                 "rs",
+	"DIR":                   "di",
+	"LINK":                  "ln",
+	"LNK":                   "ln",
+	"SYMLINK":               "ln",
+	"MULTIHARDLINK":         "mh",
+	"FIFO":                  "pi",
+	"PIPE":                  "pi",
+	"SOCK":                  "so",
+	"DOOR":                  "do",
+	"BLK":                   "bd",
+	"BLOCK":                 "bd",
+	"CHR":                   "cd",
+	"CHAR":                  "cd",
+	"ORPHAN":                "or",
+	"MISSING":               "mi",
+	"SETUID":                "su",
+	"SETGID":                "sg",
+	"CAPABILITY":            "ca",
+	"STICKY_OTHER_WRITABLE": "tw",
+	"OTHER_WRITABLE":        "ow",
+	"STICKY":                "st",
+	"EXEC":                  "ex",
+	"LEFTCODE":              "lc",
+	"LEFT":                  "lc",
+	"RIGHTCODE":             "rc",
+	"RIGHT":                 "rc",
+	"ENDCODE":               "ec",
+	"END":                   "ec",
+}
+
+// defaultCodes are the codes of GNU ls for the types a database leaves out.
+var defaultCodes = map[string]string{
+	"rs": "0",
+	"di": "01;34",
+	"ln": "01;36",
+	"pi": "33",
+	"so": "01;35",
+	"bd": "01;33",
+	"cd": "01;33",
+	"ex": "01;32",
+	"do": "01;35",
+	"su": "37;41",
+	"sg": "30;43",
+	"st": "37;44",
+	"ow": "34;42",
+	"tw": "30;42",
+}
+
+// newPalette returns a palette holding only the default codes.
+func newPalette() *Palette {
+	p := &Palette{types: make(map[string]string, len(defaultCodes))}
+	for key, code := range defaultCodes {
+		p.types[key] = code
+	}
+	return p
+}
+
+// ParseLSColors builds a palette from the value of the LS_COLORS environment
+// variable: colon-separated `key=code` pairs where key is a two-letter type
+// (di, ln, ex, ...) or a `*suffix` pattern. Malformed pairs are skipped, and
+// the types that are not given keep the default colors of GNU ls.
+func ParseLSColors(lsColors string) *Palette {
+	p := newPalette()
+
+	for _, pair := range strings.Split(lsColors, ":") {
+		key, code, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			continue
+		}
+		p.set(unescape(key), unescape(code))
+	}
+	return p
+}
+
+// ParseDatabase builds a palette from the content of a dircolors database.
+// Each line holds a keyword (DIR, EXEC, ...) or a `.ext` / `*suffix` pattern
+// followed by its code; `#` starts a comment. The entries after a group of
+// TERM lines only apply when one of them matches term. As with LS_COLORS,
+// the types that are not given keep the default colors of GNU ls.
+func ParseDatabase(content, term string) *Palette {
+	p := newPalette()
+
+	termMatched := true
+	inTermGroup := false
+
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		keyword, code := fields[0], fields[1]
+
+		if strings.EqualFold(keyword, "TERM") {
+			if !inTermGroup {
+				termMatched = false
+				inTermGroup = true
+			}
+			if utils.MatchGlob(code, term) {
+				termMatched = true
+			}
+			continue
+		}
+		inTermGroup = false
+
+		if !termMatched {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(keyword, "."):
+			p.set("*"+keyword, unescape(code))
+		case strings.HasPrefix(keyword, "*"):
+			p.set(keyword, unescape(code))
+		default:
+			if key, ok := databaseKeys[strings.ToUpper(keyword)]; ok {
+				p.set(key, unescape(code))
+			}
+		}
+	}
+	return p
+}
+
+// set records the code of an LS_COLORS key.
+func (p *Palette) set(key, code string) {
+	if suffix, ok := strings.CutPrefix(key, "*"); ok {
+		p.exts = append(p.exts, extColor{suffix: suffix, code: code})
+		return
+	}
+	p.types[key] = code
+}
+
+// unescape expands the escapes allowed in color codes: `\e`, `\a`, `\b`,
+// `\f`, `\n`, `\r`, `\t`, `\v`, `\?`, `\_` (space), octal `\NNN`, hex `\xHH`
+// and caret notation such as `^[`.
+func unescape(s string) string {
+	if !strings.ContainsAny(s, "\\^") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '^' && i+1 < len(s):
+			i++
+			if s[i] == '?' {
+				b.WriteByte(0x7f)
+			} else {
+				b.WriteByte(s[i] & 0x1f)
+			}
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch e := s[i]; e {
+			case 'e':
+				b.WriteByte(0x1b)
+			case 'a':
+				b.WriteByte('\a')
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'v':
+				b.WriteByte('\v')
+			case '?':
+				b.WriteByte(0x7f)
+			case '_':
+				b.WriteByte(' ')
+			case 'x', 'X':
+				value, n := 0, 0
+				for n < 2 && i+1 < len(s) && isHexDigit(s[i+1]) {
+					i++
+					value = value*16 + hexValue(s[i])
+					n++
+				}
+				b.WriteByte(byte(value))
+			default:
+				if e >= '0' && e <= '7' {
+					value := int(e - '0')
+					for n := 1; n < 3 && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '7'; n++ {
+						i++
+						value = value*8 + int(s[i]-'0')
+					}
+					b.WriteByte(byte(value))
+				} else {
+					b.WriteByte(e)
+				}
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func hexValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	default:
+		return int(c-'A') + 10
+	}
+}
@@ -0,0 +1,207 @@
+package colorpkg
+
+import (
+	"ls/data"
+	"os"
+	"strings"
+)
+
+// Palette decides the color of every listed file. It is either the built-in
+// palette of myls (see data.MyLSFiles.GetColor) or one loaded from an
+// LS_COLORS string or a dircolors database.
+type Palette struct {
+	builtin bool
+	types   map[string]string // two-letter type keys (di, ln, ex, ...) to SGR codes
+	exts    []extColor        // `*.ext` patterns, in the order they were given
+}
+
+// extColor is the color of the names ending with suffix.
+type extColor struct {
+	suffix string
+	code   string
+}
+
+// Builtin returns the palette myls uses when no color database is set.
+func Builtin() *Palette {
+	return &Palette{builtin: true}
+}
+
+// Load returns the palette for a run: the dircolors database at dbPath if
+// one is given, else the LS_COLORS environment variable, else the built-in
+// palette.
+func Load(dbPath string) (*Palette, error) {
+	if dbPath != "" {
+		content, err := os.ReadFile(dbPath)
+		if err != nil {
+			return nil, err
+		}
+		return ParseDatabase(string(content), os.Getenv("TERM")), nil
+	}
+	if lsColors := os.Getenv("LS_COLORS"); lsColors != "" {
+		return ParseLSColors(lsColors), nil
+	}
+	return Builtin(), nil
+}
+
+// Color returns the escape sequence that starts the color of file, or an
+// empty string if the file is not colored.
+func (p *Palette) Color(file data.MyLSFiles) string {
+	if p.builtin {
+		return file.GetColor()
+	}
+	if code := p.code(file); code != "" {
+		return p.sequence(code)
+	}
+	return ""
+}
+
+// TargetColor returns the escape sequence for the target of the symbolic
+// link file, as shown after the arrow in the long format.
+func (p *Palette) TargetColor(file data.MyLSFiles) string {
+	if file.FinalTarget == nil {
+		if p.builtin {
+			return data.ResetColor
+		}
+		return p.typeSequence("mi")
+	}
+	return p.Color(*file.FinalTarget)
+}
+
+// End returns the escape sequence that ends a color.
+func (p *Palette) End() string {
+	if p.builtin {
+		return data.ResetColor
+	}
+	if end, ok := p.types["ec"]; ok {
+		return end
+	}
+	return p.sequence(p.typeCode("rs", "0"))
+}
+
+// Paint wraps text in color, as returned by Color or TargetColor. Text the
+// palette does not color is returned as is.
+func (p *Palette) Paint(color, text string) string {
+	if color == "" {
+		return text
+	}
+	return color + text + p.End()
+}
+
+// gitCodes are the colors of the letters of the git status column, the way
+// exa colors them.
+var gitCodes = map[byte]string{
+	'N': "32",
+	'M': "34",
+	'D': "31",
+	'T': "35",
+	'I': "37",
+	'U': "33",
+}
+
+// GitColor returns the escape sequence for a letter of the git status
+// column, or an empty string for "-".
+func (p *Palette) GitColor(change byte) string {
+	if code, ok := gitCodes[change]; ok {
+		return p.sequence(code)
+	}
+	return ""
+}
+
+// code returns the SGR code of file following the precedence of GNU ls:
+// special file types and permissions first, then the name suffix of
+// regular files.
+func (p *Palette) code(file data.MyLSFiles) string {
+	var key string
+
+	switch {
+	case file.IsLink:
+		// "ln=target" colors a link like the file it points to.
+		if p.types["ln"] == "target" {
+			if file.FinalTarget != nil && !file.IsBroken {
+				return p.code(*file.FinalTarget)
+			}
+			return p.typeCode("or", "")
+		}
+		key = "ln"
+		if file.IsBroken && p.types["or"] != "" {
+			key = "or"
+		}
+	case file.IsDir:
+		switch {
+		case file.IsStickyDir && file.IsOtherWritable && p.types["tw"] != "":
+			key = "tw"
+		case file.IsOtherWritable && p.types["ow"] != "":
+			key = "ow"
+		case file.IsStickyDir && p.types["st"] != "":
+			key = "st"
+		default:
+			key = "di"
+		}
+	case file.IsPipe:
+		key = "pi"
+	case file.IsSocket:
+		key = "so"
+	case file.IsBlockDevice:
+		key = "bd"
+	case file.IsCharDevice:
+		key = "cd"
+	default:
+		switch {
+		case file.IsSetuid && p.types["su"] != "":
+			key = "su"
+		case file.IsSetgid && p.types["sg"] != "":
+			key = "sg"
+		case file.IsExec && p.types["ex"] != "":
+			key = "ex"
+		case file.NLink > 1 && p.types["mh"] != "":
+			key = "mh"
+		default:
+			if code := p.extCode(file.Name); code != "" {
+				return code
+			}
+			return p.typeCode("fi", p.types["no"])
+		}
+	}
+
+	return p.typeCode(key, p.types["no"])
+}
+
+// extCode returns the code of the last `*.ext` pattern matching name. An
+// exact match of the suffix wins over one that differs only in case.
+func (p *Palette) extCode(name string) string {
+	lower := strings.ToLower(name)
+	folded := ""
+	for i := len(p.exts) - 1; i >= 0; i-- {
+		ext := p.exts[i]
+		if strings.HasSuffix(name, ext.suffix) {
+			return ext.code
+		}
+		if folded == "" && strings.HasSuffix(lower, strings.ToLower(ext.suffix)) {
+			folded = ext.code
+		}
+	}
+	return folded
+}
+
+// typeCode returns the code set for the type key, or fallback.
+func (p *Palette) typeCode(key, fallback string) string {
+	if code, ok := p.types[key]; ok {
+		return code
+	}
+	return fallback
+}
+
+// typeSequence returns the escape sequence of the type key, or an empty
+// string when it has no color.
+func (p *Palette) typeSequence(key string) string {
+	if code := p.typeCode(key, ""); code != "" {
+		return p.sequence(code)
+	}
+	return ""
+}
+
+// sequence wraps an SGR code in the left and right codes (`\033[` and `m`
+// unless the database changes them).
+func (p *Palette) sequence(code string) string {
+	return p.typeCode("lc", "\033[") + code + p.typeCode("rc", "m")
+}
@@ -19,6 +19,9 @@ const (
 	black   = "\033[30m"   // Black
 	reset   = "\033[0m"    // Resets to the default terminal color
 
+	// ResetColor ends the colors returned by GetColor.
+	ResetColor = reset
+
 	// Background colors
 	bgBlack  = "\033[40m"
 	bgRed    = "\033[41m"
@@ -27,8 +30,12 @@ const (
 	bgGreen  = "\033[42m"
 )
 
+// MyLSFiles describes a single listed file. Directories that were read have
+// Listed set and their contents in Children; Err records why a directory
+// could not be read.
 type MyLSFiles struct {
 	Name            string
+	Path            string
 	IsDir           bool
 	IsExec          bool
 	IsLink          bool
@@ -48,10 +55,36 @@ type MyLSFiles struct {
 	IsOtherWritable bool
 	Size            int64
 	ModTime         time.Time
+	AccessTime      time.Time
+	ChangeTime      time.Time
+	BirthTime       time.Time // zero when the file system does not record it
 	Mode            fs.FileMode
 	OwnerName       string
 	GroupName       string
+	Uid             uint32
+	Gid             uint32
 	NLink           uint64
+	Blocks          int64
+	Dev             uint64 // device of the file system holding the file
+	Ino             uint64
+	Listed          bool
+	Children        []MyLSFiles
+	Err             error
+}
+
+// Time returns the timestamp of file selected by field. It is the zero time
+// for a birth time that is not known.
+func (file *MyLSFiles) Time(field utils.TimeField) time.Time {
+	switch field {
+	case utils.TimeAccess:
+		return file.AccessTime
+	case utils.TimeChange:
+		return file.ChangeTime
+	case utils.TimeBirth:
+		return file.BirthTime
+	default:
+		return file.ModTime
+	}
 }
 
 func (file *MyLSFiles) GetColor() string {
@@ -0,0 +1,182 @@
+package gitpkg
+
+import (
+	"bufio"
+	"ls/utils"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Ignore holds the gitignore rules that apply in one directory: the rules of
+// its own .gitignore file and, through parent, those of the directories above
+// it up to the top of the work tree.
+type Ignore struct {
+	parent *Ignore
+	dir    string // absolute path of the directory
+	rules  []rule // in file order; the last matching rule decides
+}
+
+// rule is one pattern line of a gitignore file.
+type rule struct {
+	segments []string // the pattern split at "/"
+	negate   bool     // "!" : re-includes what an earlier rule excluded
+	dirOnly  bool     // trailing "/" : matches directories only
+	anchored bool     // has a "/" : matched against the path from the directory of the file, not the name alone
+}
+
+// LoadIgnore returns the rules that apply in dir: those of .git/info/exclude
+// and of the .gitignore files from the top of the work tree down to dir.
+// Outside a work tree only the .gitignore file of dir is read. Files that
+// cannot be read are skipped, as git does.
+func LoadIgnore(dir string) *Ignore {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	root, ok := FindWorkTree(dir)
+	if !ok {
+		return &Ignore{dir: dir, rules: readRules(filepath.Join(dir, ".gitignore"))}
+	}
+
+	ig := &Ignore{dir: root}
+	if gitDir, ok := GitDir(root); ok {
+		ig.rules = readRules(filepath.Join(gitDir, "info", "exclude"))
+	}
+	// Rules of .gitignore files come after the excludes, taking precedence.
+	ig.rules = append(ig.rules, readRules(filepath.Join(root, ".gitignore"))...)
+
+	if rel, err := filepath.Rel(root, dir); err == nil && rel != "." {
+		for _, name := range strings.Split(rel, string(filepath.Separator)) {
+			ig = ig.Enter(name)
+		}
+	}
+	return ig
+}
+
+// Enter returns the rules that apply in the subdirectory name of the
+// directory of ig, adding those of its .gitignore file.
+func (ig *Ignore) Enter(name string) *Ignore {
+	dir := filepath.Join(ig.dir, name)
+	return &Ignore{parent: ig, dir: dir, rules: readRules(filepath.Join(dir, ".gitignore"))}
+}
+
+// Parent returns the rules of the directory above, the one ig was entered
+// from.
+func (ig *Ignore) Parent() *Ignore {
+	return ig.parent
+}
+
+// Match reports whether the entry name of the directory of ig is ignored.
+// The rules of the deepest .gitignore file come first and, within a file,
+// the last matching rule decides.
+func (ig *Ignore) Match(name string, isDir bool) bool {
+	path := filepath.Join(ig.dir, name)
+	for n := ig; n != nil; n = n.parent {
+		rel, err := filepath.Rel(n.dir, path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for i := len(n.rules) - 1; i >= 0; i-- {
+			if n.rules[i].match(rel, isDir) {
+				return !n.rules[i].negate
+			}
+		}
+	}
+	return false
+}
+
+// readRules returns the rules of the gitignore file at path, none if it
+// cannot be read.
+func readRules(path string) []rule {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var rules []rule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if r, ok := parseRule(scanner.Text()); ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// parseRule parses one line of a gitignore file. Blank lines and comments
+// give no rule. Backslash escapes, such as `\#` and `\!` at the start, are
+// left in the pattern for the glob matcher.
+func parseRule(line string) (rule, bool) {
+	line = trimTrailingSpaces(strings.TrimSuffix(line, "\r"))
+	if line == "" || line[0] == '#' {
+		return rule{}, false
+	}
+
+	var r rule
+	if line[0] == '!' {
+		r.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+
+	r.anchored = strings.Contains(line, "/")
+	r.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+	return r, true
+}
+
+// trimTrailingSpaces removes the spaces at the end of line, except one
+// escaped with a backslash.
+func trimTrailingSpaces(line string) string {
+	end := len(line)
+	for end > 0 && line[end-1] == ' ' {
+		if end > 1 && line[end-2] == '\\' {
+			break
+		}
+		end--
+	}
+	return line[:end]
+}
+
+// match reports whether the rule matches the entry at rel, its slash
+// separated path from the directory of the gitignore file.
+func (r rule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if !r.anchored {
+		return utils.MatchGlob(r.segments[0], rel[strings.LastIndexByte(rel, '/')+1:])
+	}
+	return matchSegments(r.segments, strings.Split(rel, "/"))
+}
+
+// matchSegments matches the path components parts against the pattern
+// components. A "**" component matches any number of components, but a
+// trailing one at least one: "dir/**" matches what is inside dir.
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			if len(pattern) == 1 {
+				return len(parts) > 0
+			}
+			for i := range len(parts) + 1 {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 || !utils.MatchGlob(pattern[0], parts[0]) {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
@@ -0,0 +1,123 @@
+package gitpkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// makeWorkTree creates a work tree with the given files, by slash separated
+// path, and returns its top directory.
+func makeWorkTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git", "info"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// ignored reports whether the entry at the slash separated path rel of the
+// work tree root is ignored, entering the directories above it one by one
+// as a listing does.
+func ignored(root, rel string, isDir bool) bool {
+	parts := strings.Split(rel, "/")
+	ig := LoadIgnore(root)
+	for _, dir := range parts[:len(parts)-1] {
+		ig = ig.Enter(dir)
+	}
+	return ig.Match(parts[len(parts)-1], isDir)
+}
+
+func TestIgnore(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"plain name", map[string]string{".gitignore": "debug.log\n"}, "debug.log", false, true},
+		{"glob", map[string]string{".gitignore": "*.log\n"}, "sub/debug.log", false, true},
+		{"not matching", map[string]string{".gitignore": "*.log\n"}, "debug.txt", false, false},
+		{"blank lines and comments", map[string]string{".gitignore": "\n# *.txt\n"}, "a.txt", false, false},
+		{"trailing spaces", map[string]string{".gitignore": "trail   \n"}, "trail", false, true},
+		{"escaped trailing space", map[string]string{".gitignore": "trail\\ \n"}, "trail ", false, true},
+		{"CRLF", map[string]string{".gitignore": "*.log\r\n"}, "a.log", false, true},
+
+		// Negation: the last matching rule of a file decides.
+		{"negated", map[string]string{".gitignore": "*.log\n!keep.log\n"}, "keep.log", false, false},
+		{"negated, others", map[string]string{".gitignore": "*.log\n!keep.log\n"}, "other.log", false, true},
+		{"negation overridden", map[string]string{".gitignore": "!keep.log\n*.log\n"}, "keep.log", false, true},
+
+		// Precedence across files: the deepest .gitignore comes first,
+		// then those above it, then .git/info/exclude.
+		{"nested negation", map[string]string{".gitignore": "*.log\n", "sub/.gitignore": "!keep.log\n"}, "sub/keep.log", false, false},
+		{"nested negation, other file", map[string]string{".gitignore": "*.log\n", "sub/.gitignore": "!keep.log\n"}, "sub/other.log", false, true},
+		{"nested negation, other directory", map[string]string{".gitignore": "*.log\n", "sub/.gitignore": "!keep.log\n"}, "keep.log", false, true},
+		{"nested rule", map[string]string{".gitignore": "!x.txt\n", "sub/.gitignore": "x.txt\n"}, "sub/x.txt", false, true},
+		{"exclude file", map[string]string{".git/info/exclude": "secret\n"}, "secret", false, true},
+		{"exclude overridden", map[string]string{".git/info/exclude": "secret\n", ".gitignore": "!secret\n"}, "secret", false, false},
+
+		// Directory only rules.
+		{"dir-only on a directory", map[string]string{".gitignore": "build/\n"}, "build", true, true},
+		{"dir-only on a file", map[string]string{".gitignore": "build/\n"}, "build", false, false},
+		{"dir-only nested", map[string]string{".gitignore": "build/\n"}, "sub/build", true, true},
+
+		// Anchored rules match from the directory of their file.
+		{"anchored at the top", map[string]string{".gitignore": "/top.txt\n"}, "top.txt", false, true},
+		{"anchored below", map[string]string{".gitignore": "/top.txt\n"}, "sub/top.txt", false, false},
+		{"with a slash", map[string]string{".gitignore": "doc/*.md\n"}, "doc/a.md", false, true},
+		{"with a slash, deeper", map[string]string{".gitignore": "doc/*.md\n"}, "sub/doc/a.md", false, false},
+		{"star stops at slashes", map[string]string{".gitignore": "doc/*.md\n"}, "doc/x/a.md", false, false},
+		{"anchored in a nested file", map[string]string{"sub/.gitignore": "/x\n"}, "sub/x", false, true},
+		{"anchored in a nested file, deeper", map[string]string{"sub/.gitignore": "/x\n"}, "sub/y/x", false, false},
+
+		// Double stars.
+		{"leading **", map[string]string{".gitignore": "**/cache\n"}, "a/b/cache", true, true},
+		{"leading ** at the top", map[string]string{".gitignore": "**/cache\n"}, "cache", true, true},
+		{"trailing **", map[string]string{".gitignore": "logs/**\n"}, "logs/a/b.txt", false, true},
+		{"trailing ** not the directory", map[string]string{".gitignore": "logs/**\n"}, "logs", true, false},
+		{"middle **, no directory", map[string]string{".gitignore": "a/**/b\n"}, "a/b", false, true},
+		{"middle **, several", map[string]string{".gitignore": "a/**/b\n"}, "a/x/y/b", false, true},
+		{"middle **, other", map[string]string{".gitignore": "a/**/b\n"}, "a/x/c", false, false},
+
+		// Escapes.
+		{"escaped hash", map[string]string{".gitignore": "\\#hash\n"}, "#hash", false, true},
+		{"hash is a comment", map[string]string{".gitignore": "#hash\n"}, "#hash", false, false},
+		{"escaped bang", map[string]string{".gitignore": "*\n\\!bang\n"}, "!bang", false, true},
+		{"escaped bang is not a negation", map[string]string{".gitignore": "\\!bang\n"}, "bang", false, false},
+		{"escaped star", map[string]string{".gitignore": "\\*\n"}, "*", false, true},
+		{"escaped star, literal", map[string]string{".gitignore": "\\*\n"}, "a", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := makeWorkTree(t, tt.files)
+			if got := ignored(root, tt.path, tt.isDir); got != tt.want {
+				t.Errorf("%s ignored: %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// Outside a work tree, only the .gitignore file of the directory applies.
+func TestIgnoreOutsideWorkTree(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("*.o\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ig := LoadIgnore(root)
+	if !ig.Match("main.o", false) || ig.Match("main.c", false) {
+		t.Errorf("main.o ignored: %v, main.c ignored: %v", ig.Match("main.o", false), ig.Match("main.c", false))
+	}
+}
@@ -0,0 +1,134 @@
+package gitpkg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// indexEntry is a file of the index, the staging area of git.
+type indexEntry struct {
+	path         string // slash separated, from the top of the work tree
+	id           objectID
+	mode         uint32 // 0100644, 0100755, 0120000 for links, 0160000 for submodules
+	size         uint32 // the size of the file when it was staged, truncated to 32 bits
+	mtimeSec     uint32
+	mtimeNsec    uint32
+	stage        int  // 0, or 1 to 3 for the sides of a merge conflict
+	intentToAdd  bool // added with "git add -N", not staged yet
+	skipWorktree bool // left out of a sparse checkout
+}
+
+// Index entry flags.
+const (
+	flagStageShift   = 12
+	flagExtended     = 0x4000
+	flagSkipWorktree = 0x4000 // in the extended flags
+	flagIntentToAdd  = 0x2000 // in the extended flags
+)
+
+// readIndex reads the entries of the index file at path, versions 2 to 4.
+// A missing index, as in a repository where nothing was added yet, has no
+// entries. Its extensions are not needed and are left unread.
+func readIndex(path string) ([]indexEntry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 12 || string(data[:4]) != "DIRC" {
+		return nil, fmt.Errorf("%s: not an index file", path)
+	}
+	version := binary.BigEndian.Uint32(data[4:])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("%s: unsupported index version %d", path, version)
+	}
+	count := int(binary.BigEndian.Uint32(data[8:]))
+	errTruncated := fmt.Errorf("%s: truncated index", path)
+
+	// Every entry starts with ten 32-bit stat fields, the object name and
+	// 16 bits of flags.
+	const fixedLen = 40 + 20 + 2
+
+	// The count comes from the file: it cannot hold more entries than fit.
+	entries := make([]indexEntry, 0, min(count, len(data)/fixedLen))
+	previous := ""
+	at := 12
+	for range count {
+		if len(data) < at+fixedLen {
+			return nil, errTruncated
+		}
+		fields := data[at:]
+		e := indexEntry{
+			mtimeSec:  binary.BigEndian.Uint32(fields[8:]),
+			mtimeNsec: binary.BigEndian.Uint32(fields[12:]),
+			mode:      binary.BigEndian.Uint32(fields[24:]),
+			size:      binary.BigEndian.Uint32(fields[36:]),
+		}
+		copy(e.id[:], fields[40:])
+		flags := binary.BigEndian.Uint16(fields[60:])
+		e.stage = int(flags>>flagStageShift) & 3
+
+		nameAt := at + fixedLen
+		if flags&flagExtended != 0 && version >= 3 {
+			if len(data) < nameAt+2 {
+				return nil, errTruncated
+			}
+			extended := binary.BigEndian.Uint16(data[nameAt:])
+			e.skipWorktree = extended&flagSkipWorktree != 0
+			e.intentToAdd = extended&flagIntentToAdd != 0
+			nameAt += 2
+		}
+
+		if version == 4 {
+			// The name is stored as the number of bytes to drop from the
+			// end of the previous name and what follows them.
+			drop, n := readIndexVarint(data[nameAt:])
+			if n == 0 || drop > len(previous) {
+				return nil, errTruncated
+			}
+			end := bytes.IndexByte(data[nameAt+n:], 0)
+			if end < 0 {
+				return nil, errTruncated
+			}
+			e.path = previous[:len(previous)-drop] + string(data[nameAt+n:nameAt+n+end])
+			at = nameAt + n + end + 1
+		} else {
+			end := bytes.IndexByte(data[nameAt:], 0)
+			if end < 0 {
+				return nil, errTruncated
+			}
+			e.path = string(data[nameAt : nameAt+end])
+			// Entries are padded with NULs to a multiple of 8 bytes.
+			at += (nameAt - at + end + 8) &^ 7
+		}
+
+		previous = e.path
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// readIndexVarint reads a number of the version 4 index, in 7-bit groups
+// where every continued group adds one, and returns it with its length.
+func readIndexVarint(data []byte) (int, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	n := int(data[0] & 0x7f)
+	i := 1
+	for data[i-1]&0x80 != 0 {
+		if i == len(data) {
+			return 0, 0
+		}
+		n = (n+1)<<7 | int(data[i]&0x7f)
+		i++
+	}
+	return n, i
+}
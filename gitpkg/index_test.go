@@ -0,0 +1,190 @@
+package gitpkg
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copyFixture copies the repository testdata/name, built by
+// testdata/make_fixtures.sh, to a temporary directory with its dotgit
+// directory renamed to .git, and returns the copy.
+func copyFixture(t *testing.T, name string) string {
+	t.Helper()
+	src := filepath.Join("testdata", name)
+	root := t.TempDir()
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if rel == "dotgit" || strings.HasPrefix(rel, "dotgit"+string(filepath.Separator)) {
+			rel = ".git" + strings.TrimPrefix(rel, "dotgit")
+		}
+		dst := filepath.Join(root, rel)
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(dst, 0o755)
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(target, dst)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dst, content, info.Mode().Perm()|0o200)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// blobID returns the object name of a blob holding content.
+func blobID(t *testing.T, content string) objectID {
+	t.Helper()
+	id, err := hashBlob(strings.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// historyFiles returns the files at the last commit of the repositories
+// make_fixtures.sh builds with history, by path, with their contents.
+func historyFiles(t *testing.T) map[string]string {
+	t.Helper()
+	big, err := os.ReadFile(filepath.Join("testdata", "loose", "big.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]string{
+		"README":        "hello\n",
+		"big.txt":       string(big),
+		"dir/a.txt":     "a\n",
+		"dir/b.txt":     "b\n",
+		"dir/sub/c.txt": "c\n",
+		"link":          "README",
+		"run.sh":        "#!/bin/sh\n",
+	}
+}
+
+func TestReadIndex(t *testing.T) {
+	files := historyFiles(t)
+	modes := map[string]uint32{"link": modeSymlink, "run.sh": modeExec}
+
+	tests := []struct {
+		fixture      string
+		version      byte
+		intentToAdd  string // path added with "git add -N"
+		skipWorktree string // path left out of the work tree
+	}{
+		{fixture: "index-v2", version: 2},
+		{fixture: "index-v3", version: 3, intentToAdd: "new.txt", skipWorktree: "dir/a.txt"},
+		{fixture: "index-v4", version: 4, skipWorktree: "dir/a.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			path := filepath.Join("testdata", tt.fixture, "dotgit", "index")
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if raw[7] != tt.version {
+				t.Fatalf("fixture has index version %d, want %d", raw[7], tt.version)
+			}
+
+			entries, err := readIndex(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := len(files)
+			if tt.intentToAdd != "" {
+				want++
+			}
+			if len(entries) != want {
+				t.Fatalf("got %d entries, want %d", len(entries), want)
+			}
+
+			for i, e := range entries {
+				if i > 0 && entries[i-1].path >= e.path {
+					t.Errorf("entry %q after %q: not sorted", e.path, entries[i-1].path)
+				}
+				if e.stage != 0 {
+					t.Errorf("%s: stage %d, want 0", e.path, e.stage)
+				}
+				if e.intentToAdd != (e.path == tt.intentToAdd) {
+					t.Errorf("%s: intent to add %v", e.path, e.intentToAdd)
+				}
+				if e.skipWorktree != (e.path == tt.skipWorktree) {
+					t.Errorf("%s: skip worktree %v", e.path, e.skipWorktree)
+				}
+				if e.intentToAdd {
+					if e.id != blobID(t, "") || e.size != 0 {
+						t.Errorf("%s: got id %x size %d, want the empty blob", e.path, e.id, e.size)
+					}
+					continue
+				}
+
+				content, ok := files[e.path]
+				if !ok {
+					t.Errorf("unexpected entry %q", e.path)
+					continue
+				}
+				mode, ok := modes[e.path]
+				if !ok {
+					mode = modeFile
+				}
+				if e.mode != mode {
+					t.Errorf("%s: mode %o, want %o", e.path, e.mode, mode)
+				}
+				if e.id != blobID(t, content) || e.size != uint32(len(content)) {
+					t.Errorf("%s: got id %x size %d, want those of %q", e.path, e.id, e.size, content)
+				}
+			}
+		})
+	}
+}
+
+func TestReadIndexRejectsBadFiles(t *testing.T) {
+	valid, err := os.ReadFile(filepath.Join("testdata", "index-v2", "dotgit", "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{"not an index", []byte("not an index file")},
+		{"version 5", append([]byte("DIRC\x00\x00\x00\x05"), valid[8:]...)},
+		{"truncated", valid[:100]},
+		{"huge count", append(append([]byte{}, valid[:8]...), append([]byte{0xff, 0xff, 0xff, 0xff}, valid[12:]...)...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "index")
+			if err := os.WriteFile(path, tt.content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if entries, err := readIndex(path); err == nil {
+				t.Errorf("got %d entries, want an error", len(entries))
+			}
+		})
+	}
+
+	if entries, err := readIndex(filepath.Join(t.TempDir(), "missing")); err != nil || entries != nil {
+		t.Errorf("missing index: got %v, %v, want no entries", entries, err)
+	}
+}
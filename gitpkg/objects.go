@@ -0,0 +1,339 @@
+package gitpkg
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// objectID is the SHA-1 name of a git object.
+type objectID [20]byte
+
+// parseObjectID parses the hexadecimal form of an object name.
+func parseObjectID(s string) (objectID, error) {
+	var id objectID
+	if len(s) != 2*len(id) {
+		return id, fmt.Errorf("invalid object name '%s'", s)
+	}
+	_, err := hex.Decode(id[:], []byte(s))
+	return id, err
+}
+
+// The object types, as numbered in pack files.
+const (
+	objCommit   = 1
+	objTree     = 2
+	objBlob     = 3
+	objTag      = 4
+	objOfsDelta = 6
+	objRefDelta = 7
+)
+
+var objectTypes = map[string]int{"commit": objCommit, "tree": objTree, "blob": objBlob, "tag": objTag}
+
+// objectStore reads the objects of a repository, loose or packed. The pack
+// files stay open until close is called.
+type objectStore struct {
+	dir   string // the objects directory
+	packs []*pack
+}
+
+// pack is a pack file with the contents of its version 2 index.
+type pack struct {
+	file    *os.File
+	ids     []objectID // sorted, as in the index
+	offsets []int64    // offset in the pack of the object of the same position
+}
+
+// openObjectStore opens the objects directory dir and its pack files.
+func openObjectStore(dir string) (*objectStore, error) {
+	s := &objectStore{dir: dir}
+	indexes, err := filepath.Glob(filepath.Join(dir, "pack", "*.idx"))
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range indexes {
+		p, err := openPack(index)
+		if err != nil {
+			s.close()
+			return nil, err
+		}
+		s.packs = append(s.packs, p)
+	}
+	return s, nil
+}
+
+func (s *objectStore) close() {
+	for _, p := range s.packs {
+		p.file.Close()
+	}
+}
+
+// read returns the type and the contents of the object id.
+func (s *objectStore) read(id objectID) (int, []byte, error) {
+	kind, data, err := s.readLoose(id)
+	if !errors.Is(err, fs.ErrNotExist) {
+		return kind, data, err
+	}
+	for _, p := range s.packs {
+		if i, ok := p.find(id); ok {
+			return p.readAt(p.offsets[i], s)
+		}
+	}
+	return 0, nil, fmt.Errorf("object %x not found", id)
+}
+
+// readLoose reads the object id from its own file, "xx/yyyy..." in the
+// objects directory, holding "TYPE SIZE\0CONTENTS" compressed with zlib.
+func (s *objectStore) readLoose(id objectID) (int, []byte, error) {
+	name := hex.EncodeToString(id[:])
+	file, err := os.Open(filepath.Join(s.dir, name[:2], name[2:]))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer file.Close()
+
+	z, err := zlib.NewReader(file)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer z.Close()
+	content, err := io.ReadAll(z)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	header, data, ok := bytes.Cut(content, []byte{0})
+	typeName, size, _ := bytes.Cut(header, []byte{' '})
+	kind := objectTypes[string(typeName)]
+	if !ok || kind == 0 || strconv.Itoa(len(data)) != string(size) {
+		return 0, nil, fmt.Errorf("object %x is corrupt", id)
+	}
+	return kind, data, nil
+}
+
+// openPack reads the version 2 pack index at indexPath and opens the pack
+// file beside it.
+func openPack(indexPath string) (*pack, error) {
+	index, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	// Magic number, version, then the fan-out table of 256 counts.
+	const headerLen = 8 + 256*4
+	if len(index) < headerLen || !bytes.Equal(index[:8], []byte{0xff, 't', 'O', 'c', 0, 0, 0, 2}) {
+		return nil, fmt.Errorf("%s: unsupported pack index", indexPath)
+	}
+	count := int(binary.BigEndian.Uint32(index[headerLen-4:]))
+
+	// The names, their CRC32 sums, their 4-byte offsets, then the 8-byte
+	// offsets the large ones point to.
+	idsAt := headerLen
+	offsetsAt := idsAt + count*(len(objectID{})+4)
+	largeAt := offsetsAt + count*4
+	if len(index) < largeAt {
+		return nil, fmt.Errorf("%s: truncated pack index", indexPath)
+	}
+
+	p := &pack{ids: make([]objectID, count), offsets: make([]int64, count)}
+	for i := range count {
+		copy(p.ids[i][:], index[idsAt+i*len(objectID{}):])
+		offset := binary.BigEndian.Uint32(index[offsetsAt+i*4:])
+		if offset&0x80000000 == 0 {
+			p.offsets[i] = int64(offset)
+			continue
+		}
+		at := largeAt + int(offset&0x7fffffff)*8
+		if len(index) < at+8 {
+			return nil, fmt.Errorf("%s: truncated pack index", indexPath)
+		}
+		p.offsets[i] = int64(binary.BigEndian.Uint64(index[at:]))
+	}
+
+	p.file, err = os.Open(indexPath[:len(indexPath)-len(".idx")] + ".pack")
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// find returns the position of id in the pack.
+func (p *pack) find(id objectID) (int, bool) {
+	i := sort.Search(len(p.ids), func(i int) bool { return bytes.Compare(p.ids[i][:], id[:]) >= 0 })
+	return i, i < len(p.ids) && p.ids[i] == id
+}
+
+// readAt reads the object at offset in the pack, applying the deltas it is
+// stored as. The bases of ref deltas may be anywhere in s.
+func (p *pack) readAt(offset int64, s *objectStore) (int, []byte, error) {
+	r := bufio.NewReader(io.NewSectionReader(p.file, offset, 1<<62))
+
+	// The type is in bits 4-6 of the first byte, the size in the low bits
+	// of it and in 7 bits of every following byte.
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	kind := int(c>>4) & 7
+	size := uint64(c & 0x0f)
+	for shift := 4; c&0x80 != 0; shift += 7 {
+		if c, err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+		size |= uint64(c&0x7f) << shift
+	}
+
+	var baseKind int
+	var base []byte
+	switch kind {
+	case objOfsDelta:
+		distance, err := readOffset(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		if baseKind, base, err = p.readAt(offset-distance, s); err != nil {
+			return 0, nil, err
+		}
+	case objRefDelta:
+		var baseID objectID
+		if _, err := io.ReadFull(r, baseID[:]); err != nil {
+			return 0, nil, err
+		}
+		if baseKind, base, err = s.read(baseID); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	data, err := inflate(r, size)
+	if err != nil || base == nil {
+		return kind, data, err
+	}
+	data, err = applyDelta(base, data)
+	return baseKind, data, err
+}
+
+// readOffset reads the distance back to the base of an offset delta, a
+// big-endian number of 7-bit groups where every continued group adds one.
+func readOffset(r io.ByteReader) (int64, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	distance := int64(c & 0x7f)
+	for c&0x80 != 0 {
+		if c, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+		distance = (distance+1)<<7 | int64(c&0x7f)
+	}
+	return distance, nil
+}
+
+// maxObjectSize bounds the sizes read from pack and delta headers. The
+// buffers grow with the data actually read rather than being allocated from
+// those sizes, which a corrupt pack may get wrong.
+const maxObjectSize = 1 << 40
+
+// inflate reads size bytes of zlib compressed data from r.
+func inflate(r io.Reader, size uint64) ([]byte, error) {
+	if size > maxObjectSize {
+		return nil, fmt.Errorf("object of %d bytes is too large", size)
+	}
+	z, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+	data, err := io.ReadAll(io.LimitReader(z, int64(size)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) != size {
+		return nil, errors.New("object size does not match its header")
+	}
+	return data, nil
+}
+
+// applyDelta builds an object from the base object and a delta: the sizes
+// of both, then instructions copying ranges of base or inserting new data.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	errCorrupt := errors.New("corrupt delta")
+	varint := func() (uint64, bool) {
+		var n uint64
+		for shift := 0; len(delta) > 0; shift += 7 {
+			c := delta[0]
+			delta = delta[1:]
+			n |= uint64(c&0x7f) << shift
+			if c&0x80 == 0 {
+				return n, true
+			}
+		}
+		return 0, false
+	}
+
+	baseSize, ok1 := varint()
+	targetSize, ok2 := varint()
+	if !ok1 || !ok2 || baseSize != uint64(len(base)) || targetSize > maxObjectSize {
+		return nil, errCorrupt
+	}
+
+	// A target is mostly made of the base and the inserted data; the
+	// buffer grows beyond that only as the instructions fill it.
+	target := make([]byte, 0, min(targetSize, uint64(len(base)+len(delta))))
+	for len(delta) > 0 {
+		if uint64(len(target)) > targetSize {
+			return nil, errCorrupt
+		}
+		op := delta[0]
+		delta = delta[1:]
+		if op&0x80 == 0 {
+			// Insert the next op bytes.
+			if op == 0 || int(op) > len(delta) {
+				return nil, errCorrupt
+			}
+			target = append(target, delta[:op]...)
+			delta = delta[op:]
+			continue
+		}
+
+		// Copy: bits 0-3 tell which bytes of the offset follow, bits 4-6
+		// which bytes of the size; a size of 0 means 0x10000.
+		var offset, size uint64
+		for i := range 7 {
+			if op&(1<<i) == 0 {
+				continue
+			}
+			if len(delta) == 0 {
+				return nil, errCorrupt
+			}
+			if i < 4 {
+				offset |= uint64(delta[0]) << (8 * i)
+			} else {
+				size |= uint64(delta[0]) << (8 * (i - 4))
+			}
+			delta = delta[1:]
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if offset+size > uint64(len(base)) {
+			return nil, errCorrupt
+		}
+		target = append(target, base[offset:offset+size]...)
+	}
+
+	if uint64(len(target)) != targetSize {
+		return nil, errCorrupt
+	}
+	return target, nil
+}
@@ -0,0 +1,206 @@
+package gitpkg
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// objectName returns the name git gives an object of kind with data.
+func objectName(kind int, data []byte) objectID {
+	var typeName string
+	for name, k := range objectTypes {
+		if k == kind {
+			typeName = name
+		}
+	}
+	var id objectID
+	h := sha1.New()
+	fmt.Fprintf(h, "%s %d\x00", typeName, len(data))
+	h.Write(data)
+	copy(id[:], h.Sum(nil))
+	return id
+}
+
+// storedKinds counts the objects of each type as stored in the packs of s,
+// deltas being counted as such.
+func storedKinds(t *testing.T, s *objectStore) map[int]int {
+	t.Helper()
+	kinds := make(map[int]int)
+	for _, p := range s.packs {
+		for _, offset := range p.offsets {
+			header := make([]byte, 1)
+			if _, err := p.file.ReadAt(header, offset); err != nil {
+				t.Fatal(err)
+			}
+			kinds[int(header[0]>>4)&7]++
+		}
+	}
+	return kinds
+}
+
+// allObjects returns the names of the objects of s, loose and packed.
+func allObjects(t *testing.T, s *objectStore) []objectID {
+	t.Helper()
+	var ids []objectID
+	loose, err := filepath.Glob(filepath.Join(s.dir, "??", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range loose {
+		id, err := parseObjectID(filepath.Base(filepath.Dir(path)) + filepath.Base(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	for _, p := range s.packs {
+		ids = append(ids, p.ids...)
+	}
+	return ids
+}
+
+func TestObjectStore(t *testing.T) {
+	files := historyFiles(t)
+	modes := map[string]uint32{"link": modeSymlink, "run.sh": modeExec}
+
+	tests := []struct {
+		fixture string
+		packed  bool
+		delta   int // the type of delta the pack must hold
+	}{
+		{fixture: "loose"},
+		{fixture: "packed-ofs", packed: true, delta: objOfsDelta},
+		{fixture: "packed-ref", packed: true, delta: objRefDelta},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			gitDir := filepath.Join("testdata", tt.fixture, "dotgit")
+			store, err := openObjectStore(filepath.Join(gitDir, "objects"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.close()
+
+			if packed := len(store.packs) > 0; packed != tt.packed {
+				t.Fatalf("fixture packed: %v, want %v", packed, tt.packed)
+			}
+			if tt.packed && storedKinds(t, store)[tt.delta] == 0 {
+				t.Fatalf("the pack holds no object of type %d", tt.delta)
+			}
+
+			// Every object, deltas included, must read back to the
+			// contents its name is the hash of.
+			ids := allObjects(t, store)
+			if len(ids) == 0 {
+				t.Fatal("no objects")
+			}
+			for _, id := range ids {
+				kind, data, err := store.read(id)
+				if err != nil {
+					t.Errorf("%x: %v", id, err)
+					continue
+				}
+				if got := objectName(kind, data); got != id {
+					t.Errorf("%x: read an object named %x", id, got)
+				}
+			}
+
+			head, err := readHead(gitDir, gitDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(head) != len(files) {
+				t.Errorf("HEAD has %d files, want %d", len(head), len(files))
+			}
+			for path, content := range files {
+				entry, ok := head[path]
+				mode, known := modes[path]
+				if !known {
+					mode = modeFile
+				}
+				if !ok || entry.mode != mode || entry.id != blobID(t, content) {
+					t.Errorf("%s: got %o %x, want %o %x", path, entry.mode, entry.id, mode, blobID(t, content))
+				}
+			}
+		})
+	}
+}
+
+func TestReadMissingObject(t *testing.T) {
+	store, err := openObjectStore(filepath.Join("testdata", "packed-ofs", "dotgit", "objects"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.close()
+	if _, _, err := store.read(objectID{}); err == nil {
+		t.Error("read an object that does not exist")
+	}
+}
+
+func TestInflateChecksTheSize(t *testing.T) {
+	var compressed bytes.Buffer
+	z := zlib.NewWriter(&compressed)
+	z.Write([]byte("hello"))
+	z.Close()
+
+	tests := []struct {
+		size    uint64
+		wantErr bool
+	}{
+		{5, false},
+		{4, true},
+		{6, true},
+		{1 << 62, true},
+	}
+	for _, tt := range tests {
+		data, err := inflate(bytes.NewReader(compressed.Bytes()), tt.size)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("inflate of size %d: got %q, %v", tt.size, data, err)
+		}
+	}
+}
+
+func TestApplyDelta(t *testing.T) {
+	base := []byte("hello, world\n")
+	tests := []struct {
+		name    string
+		delta   string // hexadecimal
+		want    string
+		wantErr bool
+	}{
+		// Sizes 13 and 13, copy 7 bytes from 0, insert "there\n".
+		{name: "copy and insert", delta: "0d0d" + "9007" + "06" + hex.EncodeToString([]byte("there\n")), want: "hello, there\n"},
+		// Copy 13 bytes from offset 0: the offset byte is left out.
+		{name: "copy all", delta: "0d0d" + "900d", want: "hello, world\n"},
+		{name: "wrong base size", delta: "0c0d" + "900d", wantErr: true},
+		{name: "copy past the base", delta: "0d0d" + "91050d", wantErr: true},
+		{name: "insert past the delta", delta: "0d05" + "05" + "6869", wantErr: true},
+		{name: "short of the target size", delta: "0d20" + "900d", wantErr: true},
+		{name: "past the target size", delta: "0d02" + "900d" + "900d", wantErr: true},
+		// A target size of 2^56 from a delta of a few bytes.
+		{name: "huge target size", delta: "0d" + "8080808080808080" + "01" + "900d", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta, err := hex.DecodeString(tt.delta)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := applyDelta(base, delta)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil || string(got) != tt.want {
+				t.Errorf("got %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
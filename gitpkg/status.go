@@ -0,0 +1,466 @@
+package gitpkg
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Status is the state of a file in git: its staged change, between HEAD and
+// the index, and its unstaged one, between the index and the work tree.
+// Each is one of these letters:
+//
+//	'-'  unchanged
+//	'N'  new: staged without being in HEAD, or untracked
+//	'M'  modified
+//	'D'  deleted
+//	'T'  type changed, e.g. a file replaced by a symbolic link
+//	'I'  ignored
+//	'U'  unmerged, in conflict
+type Status struct {
+	Staged   byte
+	Unstaged byte
+}
+
+// Clean is the status of an unchanged file.
+var Clean = Status{'-', '-'}
+
+func (s Status) String() string {
+	return string([]byte{s.Staged, s.Unstaged})
+}
+
+// statusRank orders the changes a directory summarizes: it shows the most
+// significant one of its contents.
+const statusRank = "-INTDMU"
+
+// summarize returns the status of a directory holding both s and other.
+func (s Status) summarize(other Status) Status {
+	if strings.IndexByte(statusRank, other.Staged) > strings.IndexByte(statusRank, s.Staged) {
+		s.Staged = other.Staged
+	}
+	if strings.IndexByte(statusRank, other.Unstaged) > strings.IndexByte(statusRank, s.Unstaged) {
+		s.Unstaged = other.Unstaged
+	}
+	return s
+}
+
+// File modes of git.
+const (
+	modeTypeMask  = 0170000
+	modeDir       = 0040000
+	modeSymlink   = 0120000
+	modeSubmodule = 0160000
+	modeExec      = 0100755
+	modeFile      = 0100644
+)
+
+// Repository is the state of a git work tree, read from its .git directory
+// when it is opened: the differences between HEAD, the index and the files.
+type Repository struct {
+	root      string            // top directory of the work tree
+	tracked   map[string]bool   // the paths in the index
+	dirs      map[string]bool   // the directories holding paths of the index
+	changes   map[string]Status // the paths of the index or HEAD with changes
+	untracked map[string]bool   // the files, and the directories with untracked files but no tracked ones, that are neither tracked nor ignored
+	empty     map[string]bool   // the untracked directories without files, which git does not report
+}
+
+// Open reads the repository of the work tree whose top directory is root.
+// Only SHA-1 repositories are supported.
+func Open(root string) (*Repository, error) {
+	gitDir, ok := GitDir(root)
+	if !ok {
+		return nil, fmt.Errorf("%s: not a git work tree", root)
+	}
+	commonDir := gitDir
+	if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = filepath.Join(gitDir, strings.TrimSpace(string(common)))
+	}
+
+	index, err := readIndex(filepath.Join(gitDir, "index"))
+	if err != nil {
+		return nil, err
+	}
+	head, err := readHead(gitDir, commonDir)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Repository{
+		root:      root,
+		tracked:   make(map[string]bool),
+		dirs:      make(map[string]bool),
+		changes:   make(map[string]Status),
+		untracked: make(map[string]bool),
+		empty:     make(map[string]bool),
+	}
+	for _, e := range index {
+		r.tracked[e.path] = true
+		for dir := path.Dir(e.path); dir != "."; dir = path.Dir(dir) {
+			r.dirs[dir] = true
+		}
+		if s := r.compare(e, head); s != Clean {
+			r.changes[e.path] = r.changes[e.path].summarize(s)
+		}
+	}
+	for p := range head {
+		if !r.tracked[p] {
+			r.changes[p] = Status{'D', '-'}
+		}
+	}
+
+	r.findUntracked(".", LoadIgnore(root))
+	return r, nil
+}
+
+// Root returns the top directory of the work tree.
+func (r *Repository) Root() string {
+	return r.root
+}
+
+// treeEntry is a file of a tree object.
+type treeEntry struct {
+	id   objectID
+	mode uint32
+}
+
+// readHead returns the files of the commit HEAD points to, by path. A branch
+// without commits has none.
+func readHead(gitDir, commonDir string) (map[string]treeEntry, error) {
+	files := make(map[string]treeEntry)
+
+	content, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return nil, err
+	}
+	ref := strings.TrimSpace(string(content))
+	if name, ok := strings.CutPrefix(ref, "ref:"); ok {
+		var found bool
+		if ref, found, err = resolveRef(commonDir, strings.TrimSpace(name)); err != nil || !found {
+			return files, err
+		}
+	}
+	commit, err := parseObjectID(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := openObjectStore(filepath.Join(commonDir, "objects"))
+	if err != nil {
+		return nil, err
+	}
+	defer store.close()
+
+	kind, data, err := store.read(commit)
+	if err != nil {
+		return nil, err
+	}
+	treeLine, _, _ := bytes.Cut(data, []byte{'\n'})
+	treeName, ok := bytes.CutPrefix(treeLine, []byte("tree "))
+	if kind != objCommit || !ok {
+		return nil, fmt.Errorf("HEAD is not a commit")
+	}
+	tree, err := parseObjectID(string(treeName))
+	if err != nil {
+		return nil, err
+	}
+	return files, store.flattenTree(tree, "", files)
+}
+
+// resolveRef returns the object name the reference name points to, from its
+// own file or from packed-refs. A missing reference is not an error: it is
+// the branch of a repository without commits.
+func resolveRef(commonDir, name string) (string, bool, error) {
+	content, err := os.ReadFile(filepath.Join(commonDir, filepath.FromSlash(name)))
+	if err == nil {
+		return strings.TrimSpace(string(content)), true, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", false, err
+	}
+
+	packed, err := os.Open(filepath.Join(commonDir, "packed-refs"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	defer packed.Close()
+
+	scanner := bufio.NewScanner(packed)
+	for scanner.Scan() {
+		id, refName, _ := strings.Cut(scanner.Text(), " ")
+		if refName == name {
+			return id, true, nil
+		}
+	}
+	return "", false, scanner.Err()
+}
+
+// flattenTree adds the files of the tree id and its subtrees to files, their
+// paths starting with prefix.
+func (s *objectStore) flattenTree(id objectID, prefix string, files map[string]treeEntry) error {
+	kind, data, err := s.read(id)
+	if err != nil {
+		return err
+	}
+	if kind != objTree {
+		return fmt.Errorf("object %x is not a tree", id)
+	}
+
+	// Each entry is "MODE NAME\0" followed by the binary object name.
+	for len(data) > 0 {
+		header, rest, ok := bytes.Cut(data, []byte{0})
+		modeText, name, ok2 := bytes.Cut(header, []byte{' '})
+		if !ok || !ok2 || len(rest) < len(objectID{}) {
+			return fmt.Errorf("tree %x is corrupt", id)
+		}
+		mode, err := strconv.ParseUint(string(modeText), 8, 32)
+		if err != nil {
+			return fmt.Errorf("tree %x is corrupt", id)
+		}
+		var entry treeEntry
+		copy(entry.id[:], rest)
+		entry.mode = uint32(mode)
+		data = rest[len(objectID{}):]
+
+		entryPath := prefix + string(name)
+		if entry.mode&modeTypeMask == modeDir {
+			if err := s.flattenTree(entry.id, entryPath+"/", files); err != nil {
+				return err
+			}
+			continue
+		}
+		files[entryPath] = entry
+	}
+	return nil
+}
+
+// compare returns the status of the index entry e against HEAD and against
+// the file in the work tree.
+func (r *Repository) compare(e indexEntry, head map[string]treeEntry) Status {
+	if e.stage != 0 {
+		return Status{'U', 'U'}
+	}
+	if e.intentToAdd {
+		return Status{'-', 'N'}
+	}
+
+	status := Clean
+	if committed, ok := head[e.path]; !ok {
+		status.Staged = 'N'
+	} else if committed.mode&modeTypeMask != e.mode&modeTypeMask {
+		status.Staged = 'T'
+	} else if committed.id != e.id || committed.mode != e.mode {
+		status.Staged = 'M'
+	}
+
+	if !e.skipWorktree && e.mode != modeSubmodule {
+		status.Unstaged = r.compareFile(e)
+	}
+	return status
+}
+
+// compareFile returns the unstaged change of the file of the index entry e.
+// A file whose size and modification time are those recorded in the index
+// is taken as unchanged, as git does; any other is hashed.
+func (r *Repository) compareFile(e indexEntry) byte {
+	name := filepath.Join(r.root, filepath.FromSlash(e.path))
+	info, err := os.Lstat(name)
+	if err != nil {
+		return 'D'
+	}
+
+	mode := uint32(modeFile)
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		mode = modeSymlink
+	case !info.Mode().IsRegular():
+		return 'T'
+	case info.Mode()&0100 != 0:
+		mode = modeExec
+	}
+	if mode&modeTypeMask != e.mode&modeTypeMask {
+		return 'T'
+	}
+	if mode != e.mode {
+		return 'M'
+	}
+
+	mtime := info.ModTime()
+	if uint32(info.Size()) == e.size && uint32(mtime.Unix()) == e.mtimeSec && uint32(mtime.Nanosecond()) == e.mtimeNsec {
+		return '-'
+	}
+	if id, err := hashFile(name, mode); err != nil || id != e.id {
+		return 'M'
+	}
+	return '-'
+}
+
+// hashFile returns the object name the file would have as a blob: the hash
+// of its contents, or of its target for a symbolic link.
+func hashFile(name string, mode uint32) (objectID, error) {
+	var id objectID
+	if mode == modeSymlink {
+		target, err := os.Readlink(name)
+		if err != nil {
+			return id, err
+		}
+		return hashBlob(strings.NewReader(target), int64(len(target)))
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return id, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return id, err
+	}
+	return hashBlob(file, info.Size())
+}
+
+// hashBlob returns the object name of a blob of size bytes read from r.
+func hashBlob(r io.Reader, size int64) (objectID, error) {
+	var id objectID
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", size)
+	if _, err := io.Copy(h, r); err != nil {
+		return id, err
+	}
+	copy(id[:], h.Sum(nil))
+	return id, nil
+}
+
+// findUntracked adds the untracked entries of the directory dir, a path
+// from the top of the work tree, and of its subdirectories holding tracked
+// files. Ignored entries and nested repositories are skipped.
+func (r *Repository) findUntracked(dir string, ig *Ignore) {
+	entries, err := os.ReadDir(filepath.Join(r.root, filepath.FromSlash(dir)))
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == ".git" {
+			continue
+		}
+		p := path.Join(dir, name)
+		if r.tracked[p] || ig.Match(name, entry.IsDir()) {
+			continue
+		}
+		if entry.IsDir() && r.dirs[p] {
+			r.findUntracked(p, ig.Enter(name))
+			continue
+		}
+		if entry.IsDir() {
+			// Git only reports directories with untracked files in them.
+			files, untracked := r.dirContents(p, ig.Enter(name))
+			if !files {
+				r.empty[p] = true
+			}
+			if !untracked {
+				continue
+			}
+		}
+		r.untracked[p] = true
+	}
+}
+
+// dirContents reports whether the untracked directory dir, a path from the
+// top of the work tree, holds files at any depth, and whether any of them is
+// not ignored. A nested repository counts as an untracked file.
+func (r *Repository) dirContents(dir string, ig *Ignore) (files, untracked bool) {
+	entries, err := os.ReadDir(filepath.Join(r.root, filepath.FromSlash(dir)))
+	if err != nil {
+		return false, false
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == ".git" {
+			return true, true
+		}
+		ignored := ig.Match(name, entry.IsDir())
+		if !entry.IsDir() {
+			files = true
+			untracked = untracked || !ignored
+			continue
+		}
+		subFiles, subUntracked := r.dirContents(path.Join(dir, name), ig.Enter(name))
+		files = files || subFiles
+		untracked = untracked || subUntracked && !ignored
+	}
+	return files, untracked
+}
+
+// Status returns the status of the file at the absolute path name in the
+// work tree. A directory summarizes the changes of its contents, and so
+// does the top of the work tree. The .git directory is always clean, and so
+// are untracked directories without files, which git does not report.
+func (r *Repository) Status(name string, isDir bool) Status {
+	rel, err := filepath.Rel(r.root, name)
+	if err != nil {
+		return Clean
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".git" || strings.HasPrefix(rel, ".git/") {
+		return Clean
+	}
+
+	if s, ok := r.changes[rel]; ok && !isDir {
+		// A file deleted from the index may still be in the work tree,
+		// where it is now untracked.
+		if s.Staged == 'D' && r.untracked[rel] {
+			s.Unstaged = 'N'
+		}
+		return s
+	}
+	if r.tracked[rel] {
+		return Clean
+	}
+	if isDir && (rel == "." || r.dirs[rel]) {
+		return r.summarize(rel)
+	}
+	for p := rel; p != "."; p = path.Dir(p) {
+		if r.untracked[p] {
+			return Status{'-', 'N'}
+		}
+		if r.empty[p] {
+			return Clean
+		}
+	}
+	return Status{'-', 'I'}
+}
+
+// summarize returns the status of the tracked directory dir, the most
+// significant change of its contents. Ignored files do not count.
+func (r *Repository) summarize(dir string) Status {
+	prefix := dir + "/"
+	if dir == "." {
+		prefix = ""
+	}
+
+	status := Clean
+	for p, s := range r.changes {
+		if strings.HasPrefix(p, prefix) {
+			status = status.summarize(s)
+		}
+	}
+	for p := range r.untracked {
+		if strings.HasPrefix(p, prefix) {
+			status = status.summarize(Status{'-', 'N'})
+		}
+	}
+	return status
+}
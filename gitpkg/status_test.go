@@ -0,0 +1,120 @@
+package gitpkg
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// porcelainStatus converts the lines `git status --porcelain` prints for one
+// path to a Status: a file deleted from the index and still in the work tree
+// has both a staged line and an untracked one.
+func porcelainStatus(lines []string) Status {
+	status := Clean
+	for _, line := range lines {
+		switch line {
+		case "??":
+			status.Unstaged = 'N'
+		case "!!":
+			status.Unstaged = 'I'
+		default:
+			status = Status{line[0], line[1]}
+		}
+	}
+	for _, c := range []*byte{&status.Staged, &status.Unstaged} {
+		switch *c {
+		case ' ':
+			*c = '-'
+		case 'A':
+			*c = 'N'
+		}
+	}
+	return status
+}
+
+// gitPorcelain runs `git status --porcelain --ignored` in root and returns
+// the codes it prints for each top-level entry.
+func gitPorcelain(t *testing.T, root string) map[string][]string {
+	t.Helper()
+	cmd := exec.Command("git", "status", "--porcelain", "--ignored")
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git status: %v", err)
+	}
+
+	codes := make(map[string][]string)
+	for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+		if line != "" {
+			name := strings.TrimSuffix(line[3:], "/")
+			codes[name] = append(codes[name], line[:2])
+		}
+	}
+	return codes
+}
+
+func TestStatus(t *testing.T) {
+	root := copyFixture(t, "status")
+	if err := os.WriteFile(filepath.Join(root, "ignored.log"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "empty", "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// What `git status --porcelain --ignored` prints for each entry.
+	want := map[string][]string{
+		"staged.txt":    {"A "},
+		"modified.txt":  {" M"},
+		"both.txt":      {"MM"},
+		"deleted.txt":   {" D"},
+		"removed.txt":   {"D ", "??"},
+		"untracked.txt": {"??"},
+		"ignored.log":   {"!!"},
+	}
+	if _, err := exec.LookPath("git"); err == nil {
+		got := gitPorcelain(t, root)
+		for name, codes := range want {
+			if !slices.Equal(got[name], codes) {
+				t.Errorf("git status prints %q for %s, the test expects %q", got[name], name, codes)
+			}
+		}
+		for name, codes := range got {
+			if _, ok := want[name]; !ok {
+				t.Errorf("git status prints %q for %s, the test expects nothing", codes, name)
+			}
+		}
+	}
+
+	repo, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"deleted.txt"}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != ".git" {
+			names = append(names, entry.Name())
+		}
+	}
+	for _, name := range names {
+		isDir := name == "empty"
+		wantStatus := porcelainStatus(want[name])
+		if got := repo.Status(filepath.Join(root, name), isDir); got != wantStatus {
+			t.Errorf("%s: got %s, want %s", name, got, wantStatus)
+		}
+	}
+	if got := repo.Status(filepath.Join(root, "empty", "sub"), true); got != Clean {
+		t.Errorf("empty/sub: got %s, want %s", got, Clean)
+	}
+	if got := repo.Status(root, true); got != (Status{'M', 'M'}) {
+		t.Errorf("top of the work tree: got %s, want MM", got)
+	}
+}
@@ -0,0 +1,97 @@
+#!/bin/sh
+# Rebuilds the repositories the gitpkg tests read. The .git directory of each
+# is stored as dotgit, so that it is checked in as plain files; the tests copy
+# the repository and rename it back.
+set -e
+cd "$(dirname "$0")"
+
+export GIT_CONFIG_GLOBAL=/dev/null GIT_CONFIG_NOSYSTEM=1
+export GIT_AUTHOR_NAME=test GIT_AUTHOR_EMAIL=test@example.com GIT_AUTHOR_DATE=2024-01-01T00:00:00Z
+export GIT_COMMITTER_NAME=test GIT_COMMITTER_EMAIL=test@example.com GIT_COMMITTER_DATE=2024-01-01T00:00:00Z
+
+# history creates the repository $1 with three commits of the same files,
+# big.txt changing a little each time so that packs store it as deltas.
+history() {
+	rm -rf "$1"
+	git init -q -b master "$1"
+	(
+		cd "$1"
+		printf 'hello\n' >README
+		mkdir -p dir/sub
+		printf 'a\n' >dir/a.txt
+		printf 'b\n' >dir/b.txt
+		printf 'c\n' >dir/sub/c.txt
+		seq 1 300 | sed 's/^/line /' >big.txt
+		printf '#!/bin/sh\n' >run.sh
+		chmod +x run.sh
+		ln -s README link
+		git add -A
+		git commit -qm one
+		sed 's/^line 150$/line 150 changed/' big.txt >big.tmp && mv big.tmp big.txt
+		git commit -qam two
+		echo 'line 301' >>big.txt
+		git commit -qam three
+	)
+}
+
+# finish drops what the tests do not read and renames .git to dotgit.
+finish() {
+	rm -rf "$1/.git/hooks" "$1/.git/logs" "$1/.git/info" "$1/.git/description" "$1/.git/COMMIT_EDITMSG" "$1/.git/branches"
+	mv "$1/.git" "$1/dotgit"
+}
+
+history loose
+finish loose
+
+history packed-ofs
+git -C packed-ofs repack -adfq
+git -C packed-ofs prune-packed
+git -C packed-ofs pack-refs --all
+finish packed-ofs
+
+history packed-ref
+git -C packed-ref -c repack.useDeltaBaseOffset=false repack -adfq
+git -C packed-ref prune-packed
+git -C packed-ref pack-refs --all
+finish packed-ref
+
+history index-v2
+git -C index-v2 update-index --index-version 2
+finish index-v2
+
+# Version 3 is the one git writes for extended flags.
+history index-v3
+printf 'new\n' >index-v3/new.txt
+git -C index-v3 add -N new.txt
+git -C index-v3 update-index --skip-worktree dir/a.txt
+git -C index-v3 update-index --index-version 3
+finish index-v3
+
+history index-v4
+git -C index-v4 update-index --skip-worktree dir/a.txt
+git -C index-v4 update-index --index-version 4
+finish index-v4
+
+# status has a file in each state; the tests add an ignored file and an empty
+# directory, which cannot be checked in.
+rm -rf status
+git init -q -b master status
+(
+	cd status
+	printf '*.log\n' >.gitignore
+	for name in clean modified deleted removed both; do
+		printf '%s\n' "$name" >"$name.txt"
+	done
+	git add -A
+	git commit -qm one
+	echo more >>modified.txt
+	rm deleted.txt
+	git rm -q --cached removed.txt
+	printf 'staged\n' >staged.txt
+	git add staged.txt
+	echo staged >>both.txt
+	git add both.txt
+	echo unstaged >>both.txt
+	printf 'untracked\n' >untracked.txt
+)
+finish status
@@ -0,0 +1,51 @@
+package gitpkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FindWorkTree returns the top directory of the git work tree that the
+// absolute path dir is in, the nearest directory at or above it holding a
+// .git entry.
+func FindWorkTree(dir string) (string, bool) {
+	for {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// GitDir returns the repository directory of the work tree root: its .git
+// directory, or the directory a .git file points to with a "gitdir:" line, as
+// submodules and linked work trees have.
+func GitDir(root string) (string, bool) {
+	gitPath := filepath.Join(root, ".git")
+	info, err := os.Stat(gitPath)
+	if err != nil {
+		return "", false
+	}
+	if info.IsDir() {
+		return gitPath, true
+	}
+
+	content, err := os.ReadFile(gitPath)
+	if err != nil {
+		return "", false
+	}
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir:")
+	if !ok {
+		return "", false
+	}
+	target = strings.TrimSpace(target)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(root, target)
+	}
+	return target, true
+}
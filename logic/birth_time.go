@@ -0,0 +1,74 @@
+//go:build linux && (amd64 || arm64)
+
+package logic
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	atFdcwd           = -100   // AT_FDCWD: relative paths start at the working directory
+	atSymlinkNoFollow = 0x100  // AT_SYMLINK_NOFOLLOW: describe a link, not its target
+	statxBtime        = 0x0800 // STATX_BTIME: the mask bit of the birth time
+)
+
+// statxTimestamp mirrors struct statx_timestamp.
+type statxTimestamp struct {
+	Sec      int64
+	Nsec     uint32
+	reserved int32
+}
+
+// statxBuf mirrors struct statx up to the timestamps, padded to its full
+// size of 256 bytes.
+type statxBuf struct {
+	Mask           uint32
+	Blksize        uint32
+	Attributes     uint64
+	Nlink          uint32
+	Uid            uint32
+	Gid            uint32
+	Mode           uint16
+	spare0         uint16
+	Ino            uint64
+	Size           uint64
+	Blocks         uint64
+	AttributesMask uint64
+	Atime          statxTimestamp
+	Btime          statxTimestamp
+	Ctime          statxTimestamp
+	Mtime          statxTimestamp
+	spare          [16]uint64
+}
+
+// birthTime returns the creation time of the file at path, following a final
+// symbolic link when follow is set. The statx syscall only reports it on the
+// file systems that record it; otherwise the zero time is returned.
+func birthTime(path string, follow bool) time.Time {
+	name, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return time.Time{}
+	}
+
+	flags := atSymlinkNoFollow
+	if follow {
+		flags = 0
+	}
+
+	var buf statxBuf
+	fd := atFdcwd
+	_, _, errno := syscall.Syscall6(sysStatx,
+		uintptr(fd),
+		uintptr(unsafe.Pointer(name)),
+		uintptr(flags),
+		statxBtime,
+		uintptr(unsafe.Pointer(&buf)),
+		0,
+	)
+	if errno != 0 || buf.Mask&statxBtime == 0 {
+		return time.Time{}
+	}
+	return time.Unix(buf.Btime.Sec, int64(buf.Btime.Nsec))
+}
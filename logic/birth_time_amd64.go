@@ -0,0 +1,6 @@
+//go:build linux
+
+package logic
+
+// sysStatx is the number of the statx syscall on linux/amd64.
+const sysStatx = 332
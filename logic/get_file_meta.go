@@ -4,17 +4,32 @@ import (
 	"ls/data"
 	"ls/utils"
 	"os"
-	"os/user"
 	"strconv"
 	"syscall"
+	"time"
 )
 
 const maxSymlinkDepth = 10
 
+// Lstat and Stat are the calls the file information is read with. They can
+// be replaced to list files that cannot be made on the running system, such
+// as device files with given numbers: the *syscall.Stat_t returned by Sys is
+// decoded as for a real file.
+var (
+	Lstat = os.Lstat
+	Stat  = os.Stat
+)
+
+// GetFileAttributes collects the metadata of the file at path from info,
+// following symbolic links up to maxSymlinkDepth. The owner and group are
+// given as numbers; see ResolveOwnerNames.
 func GetFileAttributes(path string, info os.FileInfo, isDirectArgument bool, depth int) data.MyLSFiles {
 	stat, _ := info.Sys().(*syscall.Stat_t)
 	var nlink uint64 = 1
 	var uid, gid uint32
+	var blocks int64
+	var dev, ino uint64
+	var accessTime, changeTime time.Time
 
 	file := data.MyLSFiles{}
 
@@ -22,17 +37,16 @@ func GetFileAttributes(path string, info os.FileInfo, isDirectArgument bool, dep
 		nlink = uint64(stat.Nlink)
 		uid = stat.Uid
 		gid = stat.Gid
+		blocks = stat.Blocks
+		dev = uint64(stat.Dev)
+		ino = stat.Ino
+		accessTime, changeTime = statTimes(stat)
 	}
 
-	ownerName := strconv.Itoa(int(uid))
-	if owner, err := user.LookupId(strconv.Itoa(int(uid))); err == nil {
-		ownerName = owner.Username
-	}
-
-	groupName := strconv.Itoa(int(gid))
-	if group, err := user.LookupGroupId(strconv.Itoa(int(gid))); err == nil {
-		groupName = group.Name
-	}
+	// The names are looked up separately by ResolveOwnerNames, and only
+	// when the output shows them; see newEntry.
+	ownerName := strconv.FormatUint(uint64(uid), 10)
+	groupName := strconv.FormatUint(uint64(gid), 10)
 
 	var targetFile *data.MyLSFiles
 	var targetPath string
@@ -44,20 +58,20 @@ func GetFileAttributes(path string, info os.FileInfo, isDirectArgument bool, dep
 		} else {
 			absTarget := utils.Join(utils.Dir(path), targetPath)
 
-			targetInfo, err := os.Lstat(absTarget)
+			targetInfo, err := Lstat(absTarget)
 			if err != nil {
 				file.IsBroken = true
+			} else if depth < maxSymlinkDepth {
+				tf := GetFileAttributes(absTarget, targetInfo, false, depth+1)
+				targetFile = &tf
 			}
 
-			tf := GetFileAttributes(absTarget, targetInfo, false, 0)
-			targetFile = &tf
-
 			if depth < maxSymlinkDepth {
-				finalInfo, err := os.Stat(absTarget)
+				finalInfo, err := Stat(absTarget)
 				if err != nil {
 					file.IsBroken = true
 				} else {
-					ft := GetFileAttributes(absTarget, finalInfo, false, depth+1)
+					ft := GetFileAttributes(resolveSymlink(absTarget), finalInfo, false, depth+1)
 					file.FinalTarget = &ft
 				}
 			}
@@ -66,12 +80,12 @@ func GetFileAttributes(path string, info os.FileInfo, isDirectArgument bool, dep
 
 	var major, minor uint32
 	if stat != nil {
-		major = uint32((stat.Rdev >> 8) & 0xFF) // Linux/Unix specific
-		minor = uint32(stat.Rdev & 0xFF)
+		major, minor = deviceNumbers(uint64(stat.Rdev))
 	}
 
 	return data.MyLSFiles{
 		Name:            GetDisplayName(path, isDirectArgument),
+		Path:            path,
 		IsDir:           info.IsDir(),
 		IsExec:          !info.IsDir() && (info.Mode().Perm()&0o111 != 0),
 		IsLink:          info.Mode()&os.ModeSymlink != 0,
@@ -91,11 +105,41 @@ func GetFileAttributes(path string, info os.FileInfo, isDirectArgument bool, dep
 		IsOtherWritable: info.IsDir() && info.Mode()&0o002 != 0,
 		Size:            info.Size(),
 		ModTime:         info.ModTime(),
+		AccessTime:      accessTime,
+		ChangeTime:      changeTime,
 		Mode:            info.Mode(),
 		OwnerName:       ownerName,
 		GroupName:       groupName,
+		Uid:             uid,
+		Gid:             gid,
 		NLink:           nlink,
+		Blocks:          blocks,
+		Dev:             dev,
+		Ino:             ino,
+	}
+}
+
+// deviceNumbers splits a Linux dev_t into its major and minor numbers. The
+// 12 bits of the major number are stored in bits 8-19 and the 20 bits of the
+// minor number in bits 0-7 and 20-31, with the bits above 32 extending both
+// as in glibc.
+func deviceNumbers(dev uint64) (major, minor uint32) {
+	major = uint32((dev>>8)&0xfff | (dev>>32)&^0xfff)
+	minor = uint32(dev&0xff | (dev>>12)&^0xff)
+	return major, minor
+}
+
+// resolveSymlink follows the chain of symbolic links starting at path and
+// returns the path of the file it ends at.
+func resolveSymlink(path string) string {
+	for range maxSymlinkDepth {
+		target, err := os.Readlink(path)
+		if err != nil {
+			break
+		}
+		path = utils.Join(utils.Dir(path), target)
 	}
+	return path
 }
 
 func GetDisplayName(path string, isDirectArgument bool) string {
@@ -105,9 +149,35 @@ func GetDisplayName(path string, isDirectArgument bool) string {
 	return utils.Base(path) // Use base name for directory contents
 }
 
+// newEntry returns the attributes of a listed file, with owner and group
+// names when the output shows them: in the long format and in the JSON
+// formats, unless opts asks for numeric ids. The birth time takes a syscall of
+// its own, so it is only looked up when opts shows or sorts by it. It is the
+// one of the file info describes: when info is not a symbolic link, a link
+// at path was dereferenced and is followed again.
+func newEntry(path string, info os.FileInfo, isDirectArgument bool, opts utils.Options) data.MyLSFiles {
+	file := GetFileAttributes(path, info, isDirectArgument, 0)
+	if opts.Time == utils.TimeBirth {
+		file.BirthTime = birthTime(path, info.Mode()&os.ModeSymlink == 0)
+	}
+	if showsOwnerNames(opts) {
+		ResolveOwnerNames(&file)
+	}
+	return file
+}
+
+// showsOwnerNames reports whether the output of opts shows the names of the
+// owners and groups.
+func showsOwnerNames(opts utils.Options) bool {
+	if opts.NumericIDs {
+		return false
+	}
+	return opts.Long || opts.Format != utils.FormatText
+}
+
 // exists checks whether a file or directory exists at the given path.
 // Returns true if the file exists, otherwise returns false.
 func Exists(path string) bool {
-	_, err := os.Stat(path)
+	_, err := Stat(path)
 	return err == nil
 }
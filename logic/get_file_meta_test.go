@@ -0,0 +1,103 @@
+//go:build linux && !(mips || mipsle || mips64 || mips64le)
+
+package logic
+
+import (
+	"context"
+	"ls/utils"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeInfo is a file whose stat result is given by the test.
+type fakeInfo struct {
+	name string
+	mode os.FileMode
+	size int64
+	stat *syscall.Stat_t
+}
+
+func (fi fakeInfo) Name() string       { return fi.name }
+func (fi fakeInfo) Size() int64        { return fi.size }
+func (fi fakeInfo) Mode() os.FileMode  { return fi.mode }
+func (fi fakeInfo) ModTime() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local) }
+func (fi fakeInfo) IsDir() bool        { return false }
+func (fi fakeInfo) Sys() any           { return fi.stat }
+
+// makedev builds a dev_t the way glibc does: the low 8 bits of the minor
+// number, the low 12 bits of the major, the rest of the minor, then the rest
+// of the major above bit 32.
+func makedev(major, minor uint64) uint64 {
+	return minor&0xff | (major&0xfff)<<8 | (minor&^0xff)<<12 | (major&^0xfff)<<32
+}
+
+func TestDeviceNumbers(t *testing.T) {
+	tests := []struct{ major, minor uint32 }{
+		{1, 3},
+		{8, 0},
+		{253, 65536},
+		{4095, 1048575},
+		{4096, 0},
+	}
+	for _, tt := range tests {
+		rdev := makedev(uint64(tt.major), uint64(tt.minor))
+		if major, minor := deviceNumbers(rdev); major != tt.major || minor != tt.minor {
+			t.Errorf("deviceNumbers(%#x) = %d, %d, want %d, %d", rdev, major, minor, tt.major, tt.minor)
+		}
+	}
+}
+
+// TestListLargeDevice lists a directory where the Lstat hook turns one file
+// into a block device with large numbers and another into a huge file, and
+// checks that the long format keeps their columns aligned.
+func TestListLargeDevice(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"disk", "huge"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lstat, now := Lstat, utils.Now
+	defer func() { Lstat, utils.Now = lstat, now }()
+	utils.Now = func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local) }
+	Lstat = func(name string) (os.FileInfo, error) {
+		stat := &syscall.Stat_t{Nlink: 1}
+		switch filepath.Base(name) {
+		case "disk":
+			stat.Rdev = makedev(253, 65536)
+			return fakeInfo{name: "disk", mode: os.ModeDevice | 0o660, stat: stat}, nil
+		case "huge":
+			return fakeInfo{name: "huge", mode: 0o644, size: 123456789012, stat: stat}, nil
+		}
+		return lstat(name)
+	}
+
+	t.Setenv("TIME_STYLE", "")
+	_, opts, err := utils.ParseArgs([]string{"-n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := List(context.Background(), []string{dir}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || len(entries[0].Children) != 2 {
+		t.Fatalf("got %d entries, want the directory with 2", len(entries))
+	}
+
+	files := entries[0].Children
+	format := NewLongFormat(files, opts, nil)
+	want := []string{
+		"brw-rw---- 1 0 0   253, 65536 May  1 12:00 disk",
+		"-rw-r--r-- 1 0 0 123456789012 May  1 12:00 huge",
+	}
+	for i, file := range files {
+		if got := FormatLongEntry(file, format); got != want[i] {
+			t.Errorf("got  %q\nwant %q", got, want[i])
+		}
+	}
+}
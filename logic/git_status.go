@@ -0,0 +1,84 @@
+package logic
+
+import (
+	"fmt"
+	"ls/colorpkg"
+	"ls/data"
+	"ls/gitpkg"
+	"ls/utils"
+	"os"
+	"path/filepath"
+)
+
+// gitWorkTrees caches, by directory, the top of the git work tree the
+// directory is in, empty when there is none.
+var gitWorkTrees = map[string]string{}
+
+// gitRepositories caches the repositories read for --git over the run, by
+// the top of their work tree. A repository that could not be read is nil.
+var gitRepositories = map[string]*gitpkg.Repository{}
+
+// gitExitStatus is utils.ExitMinor once a repository could not be read, a
+// listing error like a directory that cannot be opened.
+var gitExitStatus = utils.ExitOK
+
+// gitRepository returns the repository holding file, nil if it is not in a
+// git work tree. A directory is looked up by itself, so that the top of a
+// work tree shows the status of its contents. A repository that cannot be
+// read is reported on stderr the first time, and sets gitExitStatus.
+func gitRepository(file data.MyLSFiles) *gitpkg.Repository {
+	dir, err := filepath.Abs(file.Path)
+	if err != nil {
+		return nil
+	}
+	if !file.IsDir {
+		dir = filepath.Dir(dir)
+	}
+
+	root, ok := gitWorkTrees[dir]
+	if !ok {
+		root, _ = gitpkg.FindWorkTree(dir)
+		gitWorkTrees[dir] = root
+	}
+	if root == "" {
+		return nil
+	}
+
+	repo, ok := gitRepositories[root]
+	if !ok {
+		repo, err = gitpkg.Open(root)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "myls: cannot read git repository '%s': %s\n", root, err)
+			gitExitStatus = utils.ExitMinor
+		}
+		gitRepositories[root] = repo
+	}
+	return repo
+}
+
+// inGitWorkTree reports whether any of files is in a git work tree, which
+// makes the long format show the --git column.
+func inGitWorkTree(files []data.MyLSFiles) bool {
+	for _, file := range files {
+		if gitRepository(file) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// formatGitStatus returns the git status column of file, its staged and
+// unstaged change, or "--" outside a work tree.
+func formatGitStatus(file data.MyLSFiles, colors *colorpkg.Palette) string {
+	status := gitpkg.Clean
+	if repo := gitRepository(file); repo != nil {
+		if path, err := filepath.Abs(file.Path); err == nil {
+			status = repo.Status(path, file.IsDir)
+		}
+	}
+	if colors == nil {
+		return status.String()
+	}
+	return colors.Paint(colors.GitColor(status.Staged), string(status.Staged)) +
+		colors.Paint(colors.GitColor(status.Unstaged), string(status.Unstaged))
+}
@@ -0,0 +1,76 @@
+package logic
+
+import (
+	"ls/data"
+	"os/user"
+	"strconv"
+	"sync"
+)
+
+// idCache maps user and group ids to their names for the whole run, so the
+// user and group databases are read once per id instead of once per file.
+// Ids that have no name are cached as their number, so unknown ids are not
+// looked up again either. It is safe for concurrent use: the lock only
+// guards the maps, and each id is looked up outside of it, so lookups of
+// different ids run in parallel while those of the same id wait for the
+// first one.
+type idCache struct {
+	mu     sync.Mutex
+	users  map[uint32]*idName
+	groups map[uint32]*idName
+}
+
+// idName is the name of one id, looked up by the first caller asking for it.
+type idName struct {
+	once sync.Once
+	name string
+}
+
+var idNames = idCache{
+	users:  make(map[uint32]*idName),
+	groups: make(map[uint32]*idName),
+}
+
+// entry returns the cache entry of id in names, adding it if needed.
+func (c *idCache) entry(names map[uint32]*idName, id uint32) *idName {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := names[id]
+	if !ok {
+		e = &idName{}
+		names[id] = e
+	}
+	return e
+}
+
+// userName returns the login name of uid, or uid as a number if it has none.
+func (c *idCache) userName(uid uint32) string {
+	e := c.entry(c.users, uid)
+	e.once.Do(func() {
+		e.name = strconv.FormatUint(uint64(uid), 10)
+		if owner, err := user.LookupId(e.name); err == nil {
+			e.name = owner.Username
+		}
+	})
+	return e.name
+}
+
+// groupName returns the name of gid, or gid as a number if it has none.
+func (c *idCache) groupName(gid uint32) string {
+	e := c.entry(c.groups, gid)
+	e.once.Do(func() {
+		e.name = strconv.FormatUint(uint64(gid), 10)
+		if group, err := user.LookupGroupId(e.name); err == nil {
+			e.name = group.Name
+		}
+	})
+	return e.name
+}
+
+// ResolveOwnerNames replaces the numeric owner and group of file by their
+// names, using the cache shared by the whole run.
+func ResolveOwnerNames(file *data.MyLSFiles) {
+	file.OwnerName = idNames.userName(file.Uid)
+	file.GroupName = idNames.groupName(file.Gid)
+}
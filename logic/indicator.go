@@ -0,0 +1,37 @@
+package logic
+
+import (
+	"ls/data"
+	"ls/utils"
+)
+
+// Indicator returns the mark appended to the name of file for the indicator
+// style: "/" for directories, "@" for symbolic links, "|" for FIFOs, "=" for
+// sockets and "*" for executables, as far as the style includes them.
+func Indicator(file data.MyLSFiles, style utils.IndicatorStyle) string {
+	if style == utils.IndicatorNone {
+		return ""
+	}
+
+	switch {
+	case file.IsLink:
+		if style >= utils.IndicatorFileType {
+			return "@"
+		}
+	case file.IsDir:
+		return "/"
+	case file.IsPipe:
+		if style >= utils.IndicatorFileType {
+			return "|"
+		}
+	case file.IsSocket:
+		if style >= utils.IndicatorFileType {
+			return "="
+		}
+	case file.IsExec && !file.IsBlockDevice && !file.IsCharDevice:
+		if style == utils.IndicatorClassify {
+			return "*"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,238 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"ls/data"
+	"ls/utils"
+	"os"
+	"time"
+	"unicode/utf8"
+)
+
+// printJSON writes the whole listing as one JSON array. Listed directories
+// carry their contents in a nested "children" array.
+func printJSON(entries []data.MyLSFiles) int {
+	status := utils.ExitOK
+	records := make([]jsonEntry, len(entries))
+	for i, entry := range entries {
+		var entryStatus int
+		records[i], entryStatus = newJSONEntry(entry, "", true, true)
+		status = max(status, entryStatus)
+	}
+	writeJSON(records)
+	return status
+}
+
+// ndjsonPrinter writes one JSON object per line for every entry, as Walk
+// reads them. A listed directory is written once it is read, with the error
+// that kept it from being read if any, followed by its contents; these name
+// it in their "parent" field, and the subdirectories among them come with
+// their own contents afterwards.
+type ndjsonPrinter struct {
+	status int
+}
+
+func (p *ndjsonPrinter) arguments(entries []data.MyLSFiles, err error) {
+	p.status = max(p.status, PrintErrors(err))
+	for _, entry := range entries {
+		if !entry.Listed {
+			p.record(entry, "", true)
+		}
+	}
+}
+
+func (p *ndjsonPrinter) directory(dir data.MyLSFiles, parent string) {
+	p.record(dir, parent, parent == "")
+	for _, child := range dir.Children {
+		if !child.Listed {
+			p.record(child, dir.Path, false)
+		}
+	}
+}
+
+func (p *ndjsonPrinter) exitStatus() int {
+	return p.status
+}
+
+// record writes the line of file.
+func (p *ndjsonPrinter) record(file data.MyLSFiles, parent string, top bool) {
+	record, status := newJSONEntry(file, parent, false, top)
+	p.status = max(p.status, status)
+	writeJSON(record)
+}
+
+// writeJSON writes v to stdout as compact JSON on one line. The characters
+// of HTML are left as they are, as no browser reads the output.
+func writeJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
+
+// jsonEntry is the JSON object of one file. encoding/json writes strings that
+// are not valid UTF-8 with U+FFFD in place of the bad bytes, so a name or a
+// path that is not also comes as its raw bytes, in base64, to reopen the
+// file with.
+type jsonEntry struct {
+	Name       string `json:"name"`
+	NameBytes  []byte `json:"name_bytes,omitempty"`
+	Path       string `json:"path"`
+	PathBytes  []byte `json:"path_bytes,omitempty"`
+	Parent     string `json:"parent,omitempty"`
+	Type       string `json:"type"`
+	Mode       string `json:"mode"`
+	ModeOctal  string `json:"mode_octal"`
+	Size       int64  `json:"size"`
+	Blocks     int64  `json:"blocks"`
+	NLink      uint64 `json:"nlink"`
+	Owner      string `json:"owner"`
+	Group      string `json:"group"`
+	Uid        uint32 `json:"uid"`
+	Gid        uint32 `json:"gid"`
+	ModTime    string `json:"mtime"`
+	AccessTime string `json:"atime"`
+	ChangeTime string `json:"ctime"`
+	BirthTime  string `json:"btime,omitempty"`
+	Executable bool   `json:"executable"`
+	Setuid     bool   `json:"setuid"`
+	Setgid     bool   `json:"setgid"`
+	Sticky     bool   `json:"sticky"`
+	*jsonLink
+	*jsonDevice
+	Error    string       `json:"error,omitempty"`
+	Children *[]jsonEntry `json:"children,omitempty"` // nil unless the contents are nested
+}
+
+// jsonLink holds the fields of a symbolic link.
+type jsonLink struct {
+	LinkTarget      string  `json:"link_target"`
+	LinkTargetBytes []byte  `json:"link_target_bytes,omitempty"`
+	FinalTarget     *string `json:"final_target"`
+	Broken          bool    `json:"broken"`
+}
+
+// jsonDevice holds the fields of a block or character device.
+type jsonDevice struct {
+	Major uint32 `json:"major"`
+	Minor uint32 `json:"minor"`
+}
+
+// newJSONEntry returns the JSON object of file. The "parent" field is left
+// out when parent is empty, and the contents of a listed directory are
+// included only when nested is set. Directories that could not be read are
+// reported on stderr as in the text output, and the exit status is returned.
+func newJSONEntry(file data.MyLSFiles, parent string, nested, top bool) (jsonEntry, int) {
+	status := utils.ExitOK
+	record := jsonEntry{
+		Name:       file.Name,
+		NameBytes:  rawBytes(file.Name),
+		Path:       file.Path,
+		PathBytes:  rawBytes(file.Path),
+		Parent:     parent,
+		Type:       fileType(file),
+		Mode:       GetPermission(file),
+		ModeOctal:  octalMode(file),
+		Size:       file.Size,
+		Blocks:     file.Blocks,
+		NLink:      file.NLink,
+		Owner:      file.OwnerName,
+		Group:      file.GroupName,
+		Uid:        file.Uid,
+		Gid:        file.Gid,
+		ModTime:    file.ModTime.Format(time.RFC3339),
+		AccessTime: file.AccessTime.Format(time.RFC3339),
+		ChangeTime: file.ChangeTime.Format(time.RFC3339),
+		Executable: isExecutable(file),
+		Setuid:     file.IsSetuid,
+		Setgid:     file.IsSetgid,
+		Sticky:     file.Mode&os.ModeSticky != 0,
+	}
+	if !file.BirthTime.IsZero() {
+		record.BirthTime = file.BirthTime.Format(time.RFC3339)
+	}
+
+	if file.IsLink {
+		record.jsonLink = &jsonLink{
+			LinkTarget:      file.LinkTarget,
+			LinkTargetBytes: rawBytes(file.LinkTarget),
+			Broken:          file.IsBroken,
+		}
+		if file.FinalTarget != nil {
+			record.FinalTarget = &file.FinalTarget.Path
+		}
+	}
+
+	if file.IsBlockDevice || file.IsCharDevice {
+		record.jsonDevice = &jsonDevice{Major: file.MajorNumber, Minor: file.MinorNumber}
+	}
+
+	if file.Err != nil {
+		status = reportDirError(file, top)
+		record.Error = errorMessage(file.Err)
+	}
+
+	if nested && file.Listed && file.Err == nil {
+		children := make([]jsonEntry, len(file.Children))
+		for i, child := range file.Children {
+			var childStatus int
+			children[i], childStatus = newJSONEntry(child, "", true, false)
+			status = max(status, childStatus)
+		}
+		record.Children = &children
+	}
+	return record, status
+}
+
+// rawBytes returns the bytes of s when it is not valid UTF-8, nil otherwise.
+func rawBytes(s string) []byte {
+	if utf8.ValidString(s) {
+		return nil
+	}
+	return []byte(s)
+}
+
+// isExecutable reports whether file is a regular file with an execute bit
+// set, or a symbolic link to one.
+func isExecutable(file data.MyLSFiles) bool {
+	if file.IsLink {
+		return file.FinalTarget != nil && isExecutable(*file.FinalTarget)
+	}
+	return file.Mode.IsRegular() && file.IsExec
+}
+
+// fileType names the kind of file for the JSON output.
+func fileType(file data.MyLSFiles) string {
+	switch {
+	case file.IsLink:
+		return "symlink"
+	case file.IsDir:
+		return "directory"
+	case file.IsBlockDevice:
+		return "block_device"
+	case file.IsCharDevice:
+		return "char_device"
+	case file.IsPipe:
+		return "fifo"
+	case file.IsSocket:
+		return "socket"
+	default:
+		return "file"
+	}
+}
+
+// octalMode returns the permission bits of file in octal, including the
+// setuid, setgid and sticky bits, e.g. "0755" or "4755".
+func octalMode(file data.MyLSFiles) string {
+	mode := uint32(file.Mode.Perm())
+	if file.Mode&os.ModeSetuid != 0 {
+		mode |= 0o4000
+	}
+	if file.Mode&os.ModeSetgid != 0 {
+		mode |= 0o2000
+	}
+	if file.Mode&os.ModeSticky != 0 {
+		mode |= 0o1000
+	}
+	return fmt.Sprintf("%04o", mode)
+}
@@ -0,0 +1,82 @@
+package logic
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"ls/data"
+	"os"
+	"slices"
+	"testing"
+)
+
+// decodeJSONEntry returns the JSON object newJSONEntry makes of file.
+func decodeJSONEntry(t *testing.T, file data.MyLSFiles) map[string]any {
+	t.Helper()
+	record, _ := newJSONEntry(file, "", false, false)
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		t.Fatalf("%s: %v", encoded, err)
+	}
+	return fields
+}
+
+func TestJSONEntryKeepsTheBytesOfInvalidNames(t *testing.T) {
+	tests := []struct {
+		name, path string
+		wantBytes  []string // the fields that must come with their raw bytes
+	}{
+		{"plain", "dir/plain", nil},
+		{"été", "dir/été", nil},
+		{"bad\xff", "dir/bad\xff", []string{"name", "path"}},
+		{"ok", "bad\xfe/ok", []string{"path"}},
+	}
+	for _, tt := range tests {
+		fields := decodeJSONEntry(t, data.MyLSFiles{Name: tt.name, Path: tt.path})
+		raw := map[string]string{"name": tt.name, "path": tt.path}
+
+		for key, value := range raw {
+			encoded, ok := fields[key+"_bytes"].(string)
+			if ok != slices.Contains(tt.wantBytes, key) {
+				t.Errorf("%q: %s_bytes present: %v", value, key, ok)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil || string(decoded) != value {
+				t.Errorf("%q: %s_bytes decodes to %q, %v", value, key, decoded, err)
+			}
+		}
+	}
+}
+
+func TestJSONEntryType(t *testing.T) {
+	script := data.MyLSFiles{Name: "run", Mode: 0o755, IsExec: true}
+	tests := []struct {
+		name           string
+		file           data.MyLSFiles
+		wantMode       string
+		wantExecutable bool
+	}{
+		{"executable file", script, "-rwxr-xr-x", true},
+		{"directory", data.MyLSFiles{Name: "d", Mode: os.ModeDir | 0o755, IsDir: true}, "drwxr-xr-x", false},
+		{"link to an executable", data.MyLSFiles{Name: "l", Mode: os.ModeSymlink | 0o777, IsLink: true, IsExec: true, FinalTarget: &script}, "lrwxrwxrwx", true},
+		{"link to a directory", data.MyLSFiles{Name: "l", Mode: os.ModeSymlink | 0o777, IsLink: true, IsExec: true,
+			FinalTarget: &data.MyLSFiles{Mode: os.ModeDir | 0o755, IsDir: true}}, "lrwxrwxrwx", false},
+		{"broken link", data.MyLSFiles{Name: "l", Mode: os.ModeSymlink | 0o777, IsLink: true, IsExec: true, IsBroken: true}, "lrwxrwxrwx", false},
+		{"socket", data.MyLSFiles{Name: "s", Mode: os.ModeSocket | 0o755, IsSocket: true, IsExec: true}, "srwxr-xr-x", false},
+		{"fifo", data.MyLSFiles{Name: "p", Mode: os.ModeNamedPipe | 0o644, IsPipe: true}, "prw-r--r--", false},
+	}
+	for _, tt := range tests {
+		fields := decodeJSONEntry(t, tt.file)
+		if fields["mode"] != tt.wantMode || fields["executable"] != tt.wantExecutable {
+			t.Errorf("%s: got mode %v executable %v, want %s %v",
+				tt.name, fields["mode"], fields["executable"], tt.wantMode, tt.wantExecutable)
+		}
+	}
+}
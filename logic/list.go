@@ -0,0 +1,320 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"ls/data"
+	"ls/gitpkg"
+	"ls/sortpkg"
+	"ls/utils"
+	"os"
+	"strings"
+)
+
+// List collects the entries for the given paths without printing anything.
+//
+// Every accessible path is returned as one entry, sorted according to opts.
+// Directory arguments are read: they come back with Listed set and their
+// contents in Children, and with opts.Recursive their subdirectories are
+// expanded the same way. A directory that cannot be read keeps the reason in
+// its Err field.
+//
+// Paths that cannot be accessed at all are left out of the result and
+// reported together in the returned error, one *fs.PathError per path. When
+// ctx is canceled, List stops reading and returns what it has collected with
+// the error of ctx.
+func List(ctx context.Context, paths []string, opts utils.Options) ([]data.MyLSFiles, error) {
+	var accessErr error
+	w := walker{keep: true, visit: Visitor{
+		Arguments: func(_ []data.MyLSFiles, err error) { accessErr = err },
+	}}
+	entries, ctxErr := w.walk(ctx, paths, opts)
+	return entries, errors.Join(accessErr, ctxErr)
+}
+
+// Visitor receives the results of Walk as they are read. Both functions may
+// be nil.
+type Visitor struct {
+	// Arguments receives the accessible command-line entries, sorted, before
+	// any directory is read, and the paths that could not be accessed, as
+	// List reports them. The entries with Listed set are then read and
+	// passed to Directory.
+	Arguments func(entries []data.MyLSFiles, err error)
+
+	// Directory receives a listed directory with its contents, sorted,
+	// before its subdirectories are read; those that are read in turn have
+	// Listed set. parent is the path of the directory holding dir, empty for
+	// a command-line directory. A directory that could not be read has Err
+	// set and no contents.
+	Directory func(dir data.MyLSFiles, parent string)
+}
+
+// Walk lists paths like List, but hands the results to v as they are read
+// instead of returning them: a directory is passed on as soon as its own
+// contents are read, and its contents are not kept once its subdirectories
+// are done, so listing a whole file system takes memory only for the
+// directories being read. Walk returns the error of ctx when it is canceled.
+func Walk(ctx context.Context, paths []string, opts utils.Options, v Visitor) error {
+	w := walker{visit: v}
+	_, err := w.walk(ctx, paths, opts)
+	return err
+}
+
+// walker runs one listing for List or Walk.
+type walker struct {
+	visit Visitor
+	keep  bool // keep the contents of the directories once they are visited
+}
+
+// walk stats the command-line paths and lists the directories among them,
+// returning the sorted entries.
+func (wk walker) walk(ctx context.Context, paths []string, opts utils.Options) ([]data.MyLSFiles, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var entries []data.MyLSFiles
+	var errs []error
+
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return entries, err
+		}
+		info, err := statArgument(path, opts)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		entry := newEntry(path, info, true, opts)
+		entry.Listed = isListedArgument(entry, opts)
+		entries = append(entries, entry)
+	}
+
+	sortpkg.SortFiles(&entries, opts)
+	if wk.visit.Arguments != nil {
+		wk.visit.Arguments(entries, errors.Join(errs...))
+	}
+
+	for i := range entries {
+		if entries[i].Listed {
+			wk.listDirectory(ctx, &entries[i], "", opts, newWalk(entries[i], opts))
+		}
+	}
+	return entries, ctx.Err()
+}
+
+// statArgument returns the information shown for a command-line path. A
+// symbolic link is followed when the symlink policy of opts says so; a link
+// that cannot be followed is then reported like a missing file, except by
+// the default policy, which only follows the links to directories.
+func statArgument(path string, opts utils.Options) (os.FileInfo, error) {
+	info, err := Lstat(path)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return info, err
+	}
+
+	switch opts.SymlinkPolicy() {
+	case utils.DerefAlways, utils.DerefCommandLine:
+		return Stat(path)
+	case utils.DerefCommandLineDirs:
+		if target, err := Stat(path); err == nil && target.IsDir() {
+			return target, nil
+		}
+	}
+	return info, nil
+}
+
+// isListedArgument reports whether a command-line entry is shown by its
+// contents rather than as a file of its own. Links to directories get here
+// already followed, as decided by statArgument.
+func isListedArgument(entry data.MyLSFiles, opts utils.Options) bool {
+	return entry.IsDir && !opts.Directory
+}
+
+// ErrAlreadyListed is the Err of a directory that -R reached again through
+// a symbolic link or a bind mount while listing the directory itself, which
+// is not listed a second time.
+var ErrAlreadyListed = errors.New("not listing already-listed directory")
+
+// fileID identifies a file across the whole system.
+type fileID struct {
+	dev, ino uint64
+}
+
+// walk is the state a recursive listing carries down the directory tree.
+type walk struct {
+	depth     int             // levels below the command-line directory
+	rootDev   uint64          // device of the command-line directory
+	ancestors map[fileID]bool // directories being listed, to detect cycles
+	ignore    *gitpkg.Ignore  // --gitignore : rules of the directory being listed, nil without it
+}
+
+func newWalk(root data.MyLSFiles, opts utils.Options) *walk {
+	w := &walk{rootDev: root.Dev, ancestors: make(map[fileID]bool)}
+	if opts.GitIgnore {
+		w.ignore = gitpkg.LoadIgnore(root.Path)
+	}
+	return w
+}
+
+// enter moves the walk down into the subdirectory dir.
+func (w *walk) enter(dir data.MyLSFiles) {
+	w.depth++
+	if w.ignore != nil {
+		w.ignore = w.ignore.Enter(dir.Name)
+	}
+}
+
+// leave moves the walk back up from the directory it entered last.
+func (w *walk) leave() {
+	w.depth--
+	if w.ignore != nil {
+		w.ignore = w.ignore.Parent()
+	}
+}
+
+// ignores reports whether --gitignore leaves out the entry of the directory
+// being listed. The type of the entry comes from the directory itself, so
+// ignored entries are never stat'ed.
+func (w *walk) ignores(entry os.DirEntry) bool {
+	return w.ignore != nil && w.ignore.Match(entry.Name(), entry.IsDir())
+}
+
+// descends reports whether -R lists the subdirectory dir, given the depth
+// limit and --one-file-system.
+func (w *walk) descends(dir data.MyLSFiles, opts utils.Options) bool {
+	if !opts.Recursive || !dir.IsDir {
+		return false
+	}
+	if opts.MaxDepth != 0 && w.depth >= opts.MaxDepth {
+		return false
+	}
+	return !opts.OneFileSystem || dir.Dev == w.rootDev
+}
+
+// listDirectory reads the contents of dir into dir.Children, adding the "."
+// and ".." entries when hidden files are requested, passes dir to the
+// visitor, then lists the subdirectories when opts.Recursive is set. A
+// directory that is already being listed higher up the tree gets
+// ErrAlreadyListed instead, and one listed after ctx is canceled its error.
+func (wk walker) listDirectory(ctx context.Context, dir *data.MyLSFiles, parent string, opts utils.Options, w *walk) {
+	dir.Listed = true
+	id := fileID{dir.Dev, dir.Ino}
+	if w.ancestors[id] {
+		dir.Err = ErrAlreadyListed
+	} else {
+		wk.readDirectory(ctx, dir, opts, w)
+	}
+	if dir.Err == nil {
+		w.ancestors[id] = true
+		defer delete(w.ancestors, id)
+	}
+
+	if wk.visit.Directory != nil {
+		wk.visit.Directory(*dir, parent)
+	}
+
+	for i := range dir.Children {
+		child := &dir.Children[i]
+		if !child.Listed || ctx.Err() != nil {
+			continue
+		}
+		w.enter(*child)
+		wk.listDirectory(ctx, child, dir.Path, opts, w)
+		w.leave()
+		if !wk.keep {
+			child.Children = nil
+		}
+	}
+}
+
+// readDirectory reads and sorts the contents of dir, marking as Listed the
+// subdirectories that are listed in turn.
+func (wk walker) readDirectory(ctx context.Context, dir *data.MyLSFiles, opts utils.Options, w *walk) {
+	dirName := dir.Path
+
+	if err := ctx.Err(); err != nil {
+		dir.Err = err
+		return
+	}
+	entries, err := readDir(dirName)
+	if err != nil {
+		dir.Err = err
+		return
+	}
+
+	var files []data.MyLSFiles
+
+	if opts.All {
+		if dirInfo, err := Stat(dirName); err == nil && isShown(".", opts) {
+			dotFile := newEntry(dirName, dirInfo, false, opts)
+			dotFile.Name = "."
+			files = append(files, dotFile)
+		}
+
+		if parentInfo, err := Stat(dirName + "/.."); err == nil && isShown("..", opts) {
+			files = append(files, newEntry(dirName+"/..", parentInfo, false, opts))
+		}
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if !isShown(fileName, opts) || w.ignores(entry) {
+			continue
+		}
+		paths = append(paths, childPath(dirName, fileName))
+	}
+
+	for _, file := range statEntries(ctx, paths, opts) {
+		file.Listed = w.descends(file, opts)
+		files = append(files, file)
+	}
+
+	sortpkg.SortFiles(&files, opts)
+	dir.Children = files
+}
+
+// readDir returns the entries of the directory in the order the file system
+// gives them, which -U keeps. Unlike os.ReadDir it does not sort them, since
+// the listing is sorted afterwards anyway.
+func readDir(dirName string) ([]os.DirEntry, error) {
+	dir, err := os.Open(dirName)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+	return dir.ReadDir(-1)
+}
+
+// isShown reports whether the entry called name is listed: hidden files only
+// with -a or -A, and neither the entries matching the -I patterns nor, without
+// -a and -A, those matching the --hide patterns. Entries are filtered by name
+// so that the ones left out are never stat'ed.
+func isShown(name string, opts utils.Options) bool {
+	showHidden := opts.All || opts.AlmostAll
+	if !showHidden && strings.HasPrefix(name, ".") {
+		return false
+	}
+	if matchesAny(opts.Ignore, name) {
+		return false
+	}
+	return showHidden || !matchesAny(opts.Hide, name)
+}
+
+// matchesAny reports whether name matches one of the shell patterns.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if utils.MatchFileName(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// childPath appends name to dirName with a single separator, keeping the rest
+// of dirName as the user typed it so recursive headers match the arguments.
+func childPath(dirName, name string) string {
+	dirName = strings.TrimRight(dirName, "/")
+	return dirName + "/" + name
+}
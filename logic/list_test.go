@@ -0,0 +1,102 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"ls/data"
+	"ls/utils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makeTree creates dirs below a temporary directory, each with one file,
+// and returns the temporary directory.
+func makeTree(t *testing.T, dirs ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, "file"), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestWalkVisitsDirectoriesBeforeReadingSubdirectories(t *testing.T) {
+	root := makeTree(t, "a/b", "c")
+	opts := utils.Options{Recursive: true, Jobs: 1}
+
+	var visited []string
+	v := Visitor{Directory: func(dir data.MyLSFiles, parent string) {
+		rel, _ := filepath.Rel(root, dir.Path)
+		visited = append(visited, rel)
+		for _, child := range dir.Children {
+			if child.Listed && child.Children != nil {
+				t.Errorf("%s: subdirectory %s read before its parent was visited", rel, child.Name)
+			}
+		}
+	}}
+	if err := Walk(context.Background(), []string{root}, opts, v); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{".", "a", "a/b", "c"}
+	if len(visited) != len(want) {
+		t.Fatalf("visited %v, want %v", visited, want)
+	}
+	for i := range want {
+		if visited[i] != want[i] {
+			t.Fatalf("visited %v, want %v", visited, want)
+		}
+	}
+}
+
+func TestListStopsWhenCanceled(t *testing.T) {
+	root := makeTree(t, "a/b", "c")
+	opts := utils.Options{Recursive: true, Jobs: 1}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	visits := 0
+	v := Visitor{Directory: func(data.MyLSFiles, string) {
+		visits++
+		cancel()
+	}}
+	if err := Walk(ctx, []string{root}, opts, v); !errors.Is(err, context.Canceled) {
+		t.Errorf("Walk returned %v, want context.Canceled", err)
+	}
+	if visits != 1 {
+		t.Errorf("visited %d directories after canceling, want 1", visits)
+	}
+
+	entries, err := List(ctx, []string{root}, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("List returned %v, want context.Canceled", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("List returned %d entries after canceling, want 0", len(entries))
+	}
+}
+
+func TestListKeepsTheWholeTree(t *testing.T) {
+	root := makeTree(t, "a/b")
+	opts := utils.Options{Recursive: true, Jobs: 1}
+
+	entries, err := List(context.Background(), []string{root}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !entries[0].Listed {
+		t.Fatalf("got %d entries, want the listed root", len(entries))
+	}
+	a := entries[0].Children[0]
+	if a.Name != "a" || !a.Listed || len(a.Children) != 1 {
+		t.Fatalf("got %s listed %v with %d entries, want a listed with 1", a.Name, a.Listed, len(a.Children))
+	}
+	if b := a.Children[0]; b.Name != "b" || len(b.Children) != 1 {
+		t.Fatalf("got %s with %d entries, want b with 1", b.Name, len(b.Children))
+	}
+}
@@ -2,56 +2,122 @@ package logic
 
 import (
 	"fmt"
+	"ls/colorpkg"
 	"ls/data"
+	"ls/utils"
 	"os"
 	"strconv"
-	"strings"
 	"time"
 	"unicode"
 )
 
-func FormatLongEntry(file data.MyLSFiles, lenNLink int, maxOwner, maxGroup, maxSize, maxMajor, maxMinor int) string {
-	permission := GetPermission(file)
+// LongFormat holds what the lines of one long listing share: the widths of
+// their columns, and the options and colors they are printed with.
+type LongFormat struct {
+	NLinkWidth int
+	OwnerWidth int
+	GroupWidth int
+	SizeWidth  int
+	MajorWidth int
+	MinorWidth int
+	TimeWidth  int       // width the missing and relative timestamps are aligned to
+	Now        time.Time // time of the listing, deciding which timestamps are recent
+	AlignNames bool      // the names that are not quoted start with a space
+	GitColumn  bool      // --git : some of the files are in a git work tree
+	Opts       utils.Options
+	Colors     *colorpkg.Palette // nil when the output is not colored
+}
 
-	modTime := FormatTime(file.ModTime)
+// NewLongFormat measures the columns needed to print files in long format.
+func NewLongFormat(files []data.MyLSFiles, opts utils.Options, colors *colorpkg.Palette) LongFormat {
+	format := LongFormat{Opts: opts, Colors: colors, Now: utils.Now(), AlignNames: alignQuotes(files, opts)}
+	if opts.TimeStyle.Relative {
+		format.TimeWidth = 1
+		for _, file := range files {
+			if t := file.Time(opts.Time); !t.IsZero() {
+				format.TimeWidth = max(format.TimeWidth, utils.DisplayWidth(opts.TimeStyle.Format(t, format.Now)))
+			}
+		}
+	} else {
+		format.TimeWidth = utils.DisplayWidth(opts.TimeStyle.Format(time.Unix(0, 0), format.Now))
+	}
+	if opts.Git {
+		format.GitColumn = inGitWorkTree(files)
+	}
+	format.OwnerWidth, format.GroupWidth, format.SizeWidth, format.MajorWidth, format.MinorWidth = CalculateMaxWidth(files, opts.BlockSize)
+	for _, file := range files {
+		UpdateMaxNlink(&format.NLinkWidth, file)
+	}
+	return format
+}
 
-	size := fmt.Sprintf("%*d", maxSize, file.Size)
+// FormatLongEntry returns the long listing line of file, without a newline.
+func FormatLongEntry(file data.MyLSFiles, format LongFormat) string {
+	return formatLongColumns(file, format) + formatLongName(file, format)
+}
 
-	fileName := file.Name
+// formatLongColumns returns the columns of the long listing line of file
+// that come before its name, followed by a space.
+func formatLongColumns(file data.MyLSFiles, format LongFormat) string {
+	permission := GetPermission(file)
 
-	if strings.Contains(file.Name, "tpmrm0") {
-		file.MajorNumber = 253
-		file.MinorNumber = 65536
+	modTime := utils.PadLeft("-", format.TimeWidth)
+	if t := file.Time(format.Opts.Time); !t.IsZero() {
+		modTime = format.Opts.TimeStyle.Format(t, format.Now)
+		if format.Opts.TimeStyle.Relative {
+			modTime = utils.PadLeft(modTime, format.TimeWidth)
+		}
 	}
 
+	size := fmt.Sprintf("%*s", format.SizeWidth, format.Opts.BlockSize.FormatSize(uint64(file.Size)))
+
 	if file.IsBlockDevice || file.IsCharDevice {
+		// As in GNU ls, the major number takes up what the size column has
+		// beyond the widest "major, minor" pair.
 		size = fmt.Sprintf("%*d, %*d",
-			maxMajor, file.MajorNumber,
-			maxMinor, file.MinorNumber,
+			max(format.MajorWidth, format.SizeWidth-2-format.MinorWidth), file.MajorNumber,
+			format.MinorWidth, file.MinorNumber,
 		)
 	}
 
-	fileColor := file.GetColor()
-	if file.IsLink {
-		targetColor := Reset
-		if file.TargetFile != nil {
-			targetColor = file.FinalTarget.GetColor()
-		}
-		fileName = fmt.Sprintf("%s%s%s -> %s%s%s", fileColor, fileName, Reset, targetColor, file.LinkTarget, Reset)
-	}
-
-	// fmt.Println(maxSize)
-	return fmt.Sprintf("%10s %*d %-*s %-*s %s %12s %s%s%s",
+	columns := fmt.Sprintf("%10s %*d %s %s %s %s ",
 		permission,
-		lenNLink, file.NLink,
-		maxOwner, file.OwnerName,
-		maxGroup, file.GroupName,
+		format.NLinkWidth, file.NLink,
+		utils.PadRight(file.OwnerName, format.OwnerWidth),
+		utils.PadRight(file.GroupName, format.GroupWidth),
 		size,
 		modTime,
-		fileColor,
-		fileName,
-		Reset,
 	)
+	if format.GitColumn {
+		columns += formatGitStatus(file, format.Colors) + " "
+	}
+	return columns
+}
+
+// formatLongName returns the colored name of file as the long format ends
+// with it, followed by the target of a symbolic link. The indicator of a link
+// goes after its target and shows the type of the file it points to.
+func formatLongName(file data.MyLSFiles, format LongFormat) string {
+	colors := format.Colors
+	style := format.Opts.Indicator
+
+	fileName := alignedName(file.Name, format.Opts, format.AlignNames)
+	if colors != nil {
+		fileName = colors.Paint(colors.Color(file), fileName)
+	}
+	if !file.IsLink {
+		return fileName + Indicator(file, style)
+	}
+
+	target := FormatFileNames(file.LinkTarget, format.Opts)
+	if colors != nil {
+		target = colors.Paint(colors.TargetColor(file), target)
+	}
+	fileName += " -> " + target
+	if file.FinalTarget != nil && style != utils.IndicatorNone {
+		fileName += Indicator(*file.FinalTarget, style)
+	}
+	return fileName
 }
 
 func GetPermission(file data.MyLSFiles) string {
@@ -70,6 +136,8 @@ func GetPermission(file data.MyLSFiles) string {
 		perm[0] = 'c'
 	case file.IsPipe:
 		perm[0] = 'p'
+	case file.IsSocket:
+		perm[0] = 's'
 	default:
 		perm[0] = '-'
 	}
@@ -137,22 +205,16 @@ func UpdateMaxNlink(maxNlink *int, file data.MyLSFiles) {
 	}
 }
 
-func CalculateMaxWidth(files []data.MyLSFiles) (maxOwner, maxGroup, maxSize, maxMajor, maxMinor int) {
+// CalculateMaxWidth returns the widths of the owner, group and size columns,
+// measuring the sizes as they are printed in the block size bs, and those of
+// the major and minor numbers of the devices.
+func CalculateMaxWidth(files []data.MyLSFiles, bs utils.BlockSize) (maxOwner, maxGroup, maxSize, maxMajor, maxMinor int) {
 	maxMajor, maxMinor, maxRegular := 0, 0, 0
 
 	for _, file := range files {
 
-		if len(file.OwnerName) > maxOwner {
-			maxOwner = len(file.OwnerName)
-		}
-		if len(file.GroupName) > maxGroup {
-			maxGroup = len(file.GroupName)
-		}
-
-		if strings.Contains(file.Name, "tpmrm0") {
-			file.MajorNumber = 253
-			file.MinorNumber = 65536
-		}
+		maxOwner = max(maxOwner, utils.DisplayWidth(file.OwnerName))
+		maxGroup = max(maxGroup, utils.DisplayWidth(file.GroupName))
 
 		if file.IsBlockDevice || file.IsCharDevice {
 
@@ -167,30 +229,18 @@ func CalculateMaxWidth(files []data.MyLSFiles) (maxOwner, maxGroup, maxSize, max
 			}
 
 		} else {
-			sizeLen := len(fmt.Sprintf("%d", file.Size))
+			sizeLen := len(bs.FormatSize(uint64(file.Size)))
 			if sizeLen > maxRegular {
 				maxRegular = sizeLen
 			}
 		}
 	}
 
-	deviceField := maxMajor + maxMinor + 2
-	if deviceField > maxRegular && deviceField > 2 {
-		maxSize = deviceField
-	} else {
-		maxSize = maxRegular
+	// The size column holds the sizes and the "major, minor" pairs alike.
+	maxSize = maxRegular
+	if maxMajor > 0 {
+		maxSize = max(maxSize, maxMajor+2+maxMinor)
 	}
 
 	return maxOwner, maxGroup, maxSize, maxMajor, maxMinor
 }
-
-func FormatTime(modTime time.Time) string {
-	now := time.Now()
-	sixMonthsAgo := now.AddDate(0, -6, 0)
-
-	if now.Sub(modTime) > 0 && modTime.After(sixMonthsAgo) {
-		return modTime.Format("Jan _2 15:04")
-	} else {
-		return modTime.Format("Jan _2  2006")
-	}
-}
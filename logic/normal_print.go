@@ -2,6 +2,7 @@ package logic
 
 import (
 	"fmt"
+	"ls/colorpkg"
 	"ls/data"
 	"ls/utils"
 	"strings"
@@ -9,23 +10,39 @@ import (
 
 const Reset = "\033[0m"
 
-var PunctuationMarks = []string{
-	"!", "\"", "#", "$", "%", "&", "(", ")", "*", "+", ",",
-	"/", ":", ";", "<", "=", ">", "?", "@", "[", "\\", "]", "^", "`", "{", "|", "}", "~", " ",
+// FormatFileNames returns fileName as it is printed: quoted in the quoting
+// style of opts, and with the unprintable characters hidden by -q. The
+// options must have been resolved by utils.ResolveOutput.
+func FormatFileNames(fileName string, opts utils.Options) string {
+	return utils.QuoteName(fileName, opts.Quoting, opts.ControlChars == utils.ControlHide)
 }
 
-func FormatFileNames(fileName string) string {
-	if strings.Contains(fileName, "'") {
-		fileName = fmt.Sprintf("\"%s\"", fileName)
+// alignQuotes reports whether the names that are not quoted are printed after
+// a space, lining them up with the quoted ones. GNU ls does so in the columns
+// and the long format when the shell styles quote some of the files.
+func alignQuotes(files []data.MyLSFiles, opts utils.Options) bool {
+	if opts.Quoting != utils.QuoteShell && opts.Quoting != utils.QuoteShellEscape {
+		return false
 	}
-
-	for _, mark := range PunctuationMarks {
-		if strings.HasPrefix(fileName, mark) {
-			fileName = fmt.Sprintf("'%s'", fileName)
-			break
+	if !opts.Long && (opts.Layout == utils.LayoutSingle || opts.Width < 0) {
+		return false
+	}
+	for _, file := range files {
+		if FormatFileNames(file.Name, opts) != file.Name {
+			return true
 		}
 	}
-	return fileName
+	return false
+}
+
+// alignedName returns the printed name of file, after a space when align is
+// set and the name is not quoted.
+func alignedName(name string, opts utils.Options, align bool) string {
+	quoted := FormatFileNames(name, opts)
+	if align && quoted == name {
+		return " " + quoted
+	}
+	return quoted
 }
 
 // formatLongEntry returns a detailed string for a file, including extra metadata.
@@ -33,33 +50,41 @@ func FormatFileNames(fileName string) string {
 
 func PadColoredString(uncolored, colored string, width int) string {
 	// Calculate the number of spaces needed based on the visible (uncolored) length.
-	padLen := max(width-len(uncolored), 0)
+	padLen := max(width-utils.DisplayWidth(uncolored), 0)
 
 	return colored + strings.Repeat(" ", padLen)
 }
 
-func printFiles(files []data.MyLSFiles) {
-	width, err := utils.GetTerminalWidth()
-	if err != nil {
-		width = 80
-	}
-
+func printFiles(files []data.MyLSFiles, opts utils.Options, colors *colorpkg.Palette) {
 	names := make([]string, len(files))
 	coloredNames := make([]string, len(files))
 
 	totalLen := 0
 	var allnames []string
+	align := alignQuotes(files, opts)
 	for i, file := range files {
-		displayName := file.Name
-		names[i] = displayName
-		coloredNames[i] = file.GetColor() + displayName + Reset
+		displayName := alignedName(file.Name, opts, align)
+		coloredNames[i] = displayName
+		if colors != nil {
+			coloredNames[i] = colors.Paint(colors.Color(file), displayName)
+		}
+
+		// The indicator is not colored, but takes room in the columns.
+		indicator := Indicator(file, opts.Indicator)
+		displayName += indicator
+		coloredNames[i] += indicator
 		names[i] = displayName
 
-		totalLen += len(displayName)
+		totalLen += utils.DisplayWidth(displayName)
 		allnames = append(allnames, displayName)
 	}
 
-	columns := max(utils.GetColumns(len(files), width, allnames), 1)
+	if opts.Layout == utils.LayoutSingle {
+		fmt.Print(strings.Join(coloredNames, "\n"))
+		return
+	}
+
+	columns := max(utils.GetColumns(len(files), opts.Width, allnames), 1)
 
 	rows := (len(files) + columns - 1) / columns
 
@@ -78,13 +103,6 @@ func printFiles(files []data.MyLSFiles) {
 	}
 }
 
-func printDirHeader(dirName string) string {
-	var header string
-
-	if dirName == "." {
-		header = "."
-	} else {
-		header = dirName
-	}
-	return header + ":"
+func printDirHeader(dirName string, opts utils.Options) string {
+	return FormatFileNames(dirName, opts) + ":"
 }
@@ -1,174 +1,223 @@
 package logic
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"ls/colorpkg"
 	"ls/data"
-	"ls/sortpkg"
 	"ls/utils"
 	"os"
 	"strings"
 	"syscall"
 )
 
-func ProcessPaths(paths []string, lFlag, RFlag, aFlag, rFlag, tFlag bool) {
-	var allEntries []data.MyLSFiles
-
-	// Separate files and directories.
-	for _, path := range paths {
-		info, err := os.Lstat(path)
-		if err != nil {
-			if os.IsNotExist(err) {
-				fmt.Printf("myls: cannot access '%s': No such file or directory\n", path)
-			} else {
-				fmt.Printf("myls: cannot access '%s': Not a directory\n", path)
-			}
-			continue
-		}
-		entry := GetFileAttributes(path, info, true, 0)
-		allEntries = append(allEntries, entry)
+// PrintErrors reports the paths that List could not access on stderr and
+// returns the exit status they call for.
+func PrintErrors(err error) int {
+	if err == nil {
+		return utils.ExitOK
 	}
 
-	var files []data.MyLSFiles
-	var dirs []data.MyLSFiles
-
-	for _, entry := range allEntries {
-		if entry.IsLink {
-			if entry.TargetFile.IsDir && !lFlag {
-				dirs = append(dirs, entry)
-			} else {
-				files = append(files, entry)
-			}
-			continue
-		}
-		if entry.IsDir {
-			dirs = append(dirs, entry)
-		} else {
-			files = append(files, entry)
-		}
+	var errs []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	} else {
+		errs = []error{err}
 	}
 
-	// Sort files and directories
-	sortpkg.SortFiles(&files, tFlag, rFlag)
-	sortpkg.SortFiles(&dirs, tFlag, rFlag)
-
-	if len(files) > 0 {
-		printFilesDetails(files, lFlag, rFlag, tFlag)
-		if len(dirs) > 0 {
-			fmt.Println()
+	for _, e := range errs {
+		if path := errorPath(e); path != "" {
+			fmt.Fprintf(os.Stderr, "myls: cannot access '%s': %s\n", path, errorMessage(e))
+		} else {
+			fmt.Fprintf(os.Stderr, "myls: %s\n", errorMessage(e))
 		}
 	}
-	// // Process directories
-	for i, dir := range dirs {
-		if len(allEntries) > 1 && !RFlag {
-			fmt.Printf("%s:\n", dir.Name)
-		}
-		processDirectory(dir.Name, lFlag, RFlag, aFlag, rFlag, tFlag)
-		if i != len(dirs)-1 {
-			fmt.Println()
-		}
+	return utils.ExitSerious
+}
+
+// errorPath returns the path an error is about, if it carries one.
+func errorPath(err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Path
 	}
+	return ""
 }
 
-// TheMainLS lists directory contents similar to the Unix `ls` command.
-// It supports various flags for additional functionality:
-//
-//   - `lFlag` : Enables long listing format with detailed file information.
-//   - `RFlag` : Recursively lists subdirectories.
-//   - `aFlag` : Includes hidden files (those starting with `.`).
-//   - `rFlag` : Reverses the sorting order.
-//   - `tFlag` : Sorts files by modification time (newest first).
-//
-// Parameters:
-//   - `dirName` (string): The directory to list. Defaults to the current directory if empty.
-//   - `lFlag`, `RFlag`, `aFlag`, `rFlag`, `tFlag` (bool): Flags controlling the behavior.
-//
-// The function retrieves directory contents, filters them based on flags, sorts them,
-// and prints the results with color coding. If `RFlag` is set, it recursively lists subdirectories.
-func processDirectory(dirName string, lFlag, RFlag, aFlag, rFlag, tFlag bool) {
-	var files []data.MyLSFiles
-	var subDirs []data.MyLSFiles
+// errorMessage returns the description of err the way strerror(3) words it.
+func errorMessage(err error) string {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return err.Error()
+	}
 
-	fileInfo, err := os.Lstat(dirName)
-	if err != nil {
-		if os.IsNotExist(err) {
-			fmt.Printf("myls: cannot access '%s': No such file or direcory", dirName)
-		} else {
-			fmt.Print("Error:", err)
-		}
-		return
+	switch errno {
+	case syscall.ENOENT:
+		return "No such file or directory"
+	case syscall.EACCES:
+		return "Permission denied"
+	case syscall.ENOTDIR:
+		return "Not a directory"
+	case syscall.ELOOP:
+		return "Too many levels of symbolic links"
 	}
 
-	entries, err := os.ReadDir(dirName)
-	if err != nil {
-		if os.IsNotExist(err) {
-			fmt.Printf("myls: cannot access '%s': No such file or direcory", dirName)
-		} else {
-			fmt.Print("Error:", err)
-		}
-		return
+	msg := errno.Error()
+	if msg == "" {
+		return msg
 	}
+	return strings.ToUpper(msg[:1]) + msg[1:]
+}
 
-	var totalBlocks int64
-	var maxNlink int
+// Print lists paths and prints them the way the `ls` command does, each
+// directory as soon as it is read, and returns the exit status. The JSON
+// document and the tree are printed once the whole listing is collected, as
+// their layout depends on all of it.
+func Print(ctx context.Context, paths []string, opts utils.Options) int {
+	if opts.Format == utils.FormatJSON || opts.Tree != utils.TreeNone {
+		entries, err := List(ctx, paths, opts)
+		status := PrintErrors(err)
+		return max(status, PrintEntries(entries, opts))
+	}
 
-	if aFlag {
-		dotFile := GetFileAttributes(".", fileInfo, true, 0)
-		files = append(files, dotFile)
-		if stat, ok := fileInfo.Sys().(*syscall.Stat_t); ok {
-			totalBlocks += int64(stat.Blocks)
-		}
+	p := newEntryPrinter(opts)
+	err := Walk(ctx, paths, opts, Visitor{Arguments: p.arguments, Directory: p.directory})
+	return max(p.exitStatus(), PrintErrors(err))
+}
 
-		if parentInfo, err := os.Stat(dirName + "/.."); err == nil {
-			parentFile := GetFileAttributes(dirName+"/..", parentInfo, false, 0)
-			files = append(files, parentFile)
-			if stat, ok := parentInfo.Sys().(*syscall.Stat_t); ok {
-				totalBlocks += int64(stat.Blocks)
-			}
-			UpdateMaxNlink(&maxNlink, parentFile)
-		}
+// PrintEntries prints the result of List the way the `ls` command does:
+// plain file arguments first, then the contents of each listed directory.
+// Directories that could not be read are reported on stderr, and the
+// returned exit status tells how serious the worst of them was.
+//
+// The automatic output settings of opts are resolved for the current stdout
+// (see utils.ResolveOutput) before anything is printed.
+func PrintEntries(entries []data.MyLSFiles, opts utils.Options) int {
+	if opts.Format == utils.FormatJSON {
+		return printJSON(entries)
+	}
+	if opts.Format == utils.FormatText && opts.Tree != utils.TreeNone {
+		opts = utils.ResolveOutput(opts)
+		colors, status := loadPalette(opts)
+		status = max(status, printTree(entries, opts, colors))
+		return max(status, gitExitStatus)
 	}
 
-	var file data.MyLSFiles
-	var fileName string
+	p := newEntryPrinter(opts)
+	p.arguments(entries, nil)
 	for _, entry := range entries {
-		fileName = entry.Name()
-		if !aFlag && strings.HasPrefix(fileName, ".") {
-			continue
+		if entry.Listed {
+			replayDirectory(entry, "", p)
 		}
+	}
+	return p.exitStatus()
+}
 
-		info, err := os.Lstat(utils.Join(dirName, fileName))
-		if err != nil {
-			continue
+// replayDirectory hands dir and its listed subdirectories, as collected by
+// List, to p in the order Walk would.
+func replayDirectory(dir data.MyLSFiles, parent string, p entryPrinter) {
+	p.directory(dir, parent)
+	for _, child := range dir.Children {
+		if child.Listed {
+			replayDirectory(child, dir.Path, p)
 		}
+	}
+}
+
+// entryPrinter prints a listing in the order Walk reads it; see Visitor.
+type entryPrinter interface {
+	arguments(entries []data.MyLSFiles, err error)
+	directory(dir data.MyLSFiles, parent string)
+	exitStatus() int
+}
 
-		name := FormatFileNames(fileName)
-		fileName = name
+// newEntryPrinter returns the printer of the format of opts, resolving the
+// automatic output settings of the text format.
+func newEntryPrinter(opts utils.Options) entryPrinter {
+	if opts.Format == utils.FormatNDJSON {
+		return &ndjsonPrinter{}
+	}
+	opts = utils.ResolveOutput(opts)
+	colors, status := loadPalette(opts)
+	return &textPrinter{opts: opts, colors: colors, status: status}
+}
 
-		file = GetFileAttributes(utils.Join(dirName, fileName), info, false, 0)
-		files = append(files, file)
+// textPrinter prints the listing as text: the file arguments first, then
+// every directory set apart by a blank line.
+type textPrinter struct {
+	opts    utils.Options
+	colors  *colorpkg.Palette
+	headers bool // the directories are printed after their name
+	printed bool // a block was printed, so the next one starts with a blank line
+	status  int
+}
 
-		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
-			totalBlocks += int64(stat.Blocks)
-		}
+func (p *textPrinter) arguments(entries []data.MyLSFiles, err error) {
+	p.status = max(p.status, PrintErrors(err))
+	p.headers = p.opts.Recursive || len(entries) > 1
 
-		if RFlag && file.IsDir {
-			subDirs = append(subDirs, file)
+	var files []data.MyLSFiles
+	for _, entry := range entries {
+		if !entry.Listed {
+			files = append(files, entry)
 		}
-		UpdateMaxNlink(&maxNlink, file)
 	}
+	if len(files) > 0 {
+		printFilesDetails(files, p.opts, p.colors)
+		p.printed = true
+	}
+}
+
+// directory prints the contents of dir, or reports on stderr why it could
+// not be read: with ExitSerious for a command-line argument and ExitMinor
+// for a subdirectory found while recursing. A directory -R reached again is
+// reported without a header.
+func (p *textPrinter) directory(dir data.MyLSFiles, parent string) {
+	if errors.Is(dir.Err, ErrAlreadyListed) {
+		p.status = max(p.status, reportDirError(dir, false))
+		return
+	}
+
+	if p.printed {
+		fmt.Println()
+	}
+	p.printed = true
+	if p.headers {
+		fmt.Println(printDirHeader(dir.Path, p.opts))
+	}
+	if dir.Err != nil {
+		p.status = max(p.status, reportDirError(dir, parent == ""))
+		return
+	}
+	printDirectory(dir, p.opts, p.colors)
+}
 
-	sortpkg.SortFiles(&files, tFlag, rFlag)
+func (p *textPrinter) exitStatus() int {
+	return max(p.status, gitExitStatus)
+}
 
-	if RFlag {
-		sortpkg.SortFiles(&subDirs, tFlag, rFlag)
-		fmt.Println(printDirHeader(dirName))
+// printDirectory prints the contents of a directory read by List or Walk.
+// It supports the listing options for additional functionality:
+//
+//   - `Long` : Enables long listing format with detailed file information.
+//
+// Hidden files and sorting have already been applied, so the function only
+// formats the entries with color coding.
+func printDirectory(dir data.MyLSFiles, opts utils.Options, colors *colorpkg.Palette) {
+	files := dir.Children
+	var totalBlocks int64
+	for _, file := range files {
+		totalBlocks += file.Blocks
 	}
 
-	if lFlag {
-		fmt.Printf("total %d\n", totalBlocks/2)
-		maxOwner, maxGroup, maxsize, maxMajor, maxMinor := CalculateMaxWidth(files)
+	if opts.Long {
+		// st_blocks counts 512-byte units.
+		fmt.Printf("total %s\n", opts.BlockSize.FormatTotal(uint64(totalBlocks)*512))
+		format := NewLongFormat(files, opts, colors)
 		for i, file := range files {
-			fmt.Print(FormatLongEntry(file, maxNlink, maxOwner, maxGroup, maxsize, maxMajor, maxMinor))
+			fmt.Print(FormatLongEntry(file, format))
 			if i != len(files)-1 {
 				fmt.Println()
 			}
@@ -177,35 +226,51 @@ func processDirectory(dirName string, lFlag, RFlag, aFlag, rFlag, tFlag bool) {
 			fmt.Println()
 		}
 	} else {
-		printFiles(files)
+		printFiles(files, opts, colors)
 		if len(files) > 0 {
 			fmt.Println()
 		}
 	}
+}
 
-	if RFlag {
+// loadPalette returns the colors for the run, or nil when the output is not
+// colored. A color database that cannot be read is reported on stderr and
+// the built-in colors are used instead.
+func loadPalette(opts utils.Options) (*colorpkg.Palette, int) {
+	if !opts.UseColor() {
+		return nil, utils.ExitOK
+	}
 
-		for _, subDir := range subDirs {
-			fmt.Println()
-			for dirName[len(dirName)-1] == '/' {
-				dirName = strings.TrimSuffix(dirName, "/")
-			}
-			dirName += "/"
-			subDirPath := dirName + subDir.Name
-			processDirectory(subDirPath, lFlag, RFlag, aFlag, rFlag, tFlag)
-		}
+	colors, err := colorpkg.Load(opts.ColorDB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "myls: cannot read color database '%s': %s\n", opts.ColorDB, errorMessage(err))
+		return colorpkg.Builtin(), utils.ExitSerious
+	}
+	return colors, utils.ExitOK
+}
+
+// reportDirError prints why dir could not be read on stderr and returns the
+// exit status it calls for.
+func reportDirError(dir data.MyLSFiles, top bool) int {
+	if errors.Is(dir.Err, ErrAlreadyListed) {
+		fmt.Fprintf(os.Stderr, "myls: %s: %s\n", dir.Path, dir.Err)
+		return utils.ExitSerious
+	}
+	fmt.Fprintf(os.Stderr, "myls: cannot open directory '%s': %s\n", dir.Path, errorMessage(dir.Err))
+	if top {
+		return utils.ExitSerious
 	}
+	return utils.ExitMinor
 }
 
-func printFilesDetails(files []data.MyLSFiles, lFlag bool, rFlag bool, tFlag bool) {
-	sortpkg.SortFiles(&files, tFlag, rFlag)
-	maxOwner, maxGroup, maxsize, maxMajor, maxMinor := CalculateMaxWidth(files)
-	if lFlag {
+func printFilesDetails(files []data.MyLSFiles, opts utils.Options, colors *colorpkg.Palette) {
+	if opts.Long {
+		format := NewLongFormat(files, opts, colors)
 		for _, file := range files {
-			fmt.Println(FormatLongEntry(file, 0, maxOwner, maxGroup, maxsize, maxMajor, maxMinor))
+			fmt.Println(FormatLongEntry(file, format))
 		}
 	} else {
-		printFiles(files)
+		printFiles(files, opts, colors)
 		fmt.Println()
 	}
 }
@@ -0,0 +1,87 @@
+package logic
+
+import (
+	"context"
+	"ls/data"
+	"ls/utils"
+	"os"
+)
+
+// defaultJobs is the number of stat workers used when Options.Jobs is 0.
+// On a local disk the inode cache answers every lookup, and the workers cost
+// more than they save: BenchmarkStatEntries takes 12-13ms serially and
+// 14-18ms with 2 to 16 workers. When every lookup waits on a server, as on
+// NFS, they pay off: BenchmarkStatEntriesSlowDisk goes from 620ms serially
+// to 82ms with 8 workers and 42ms with 16. The default is for the common
+// case; --jobs is for the slow one.
+const defaultJobs = 1
+
+// statEntries collects the attributes of every path, running up to opts.Jobs
+// lookups at the same time. The result keeps the order of paths; paths that
+// can no longer be accessed (e.g. removed since the directory was read) are
+// left out, and so are those not looked up yet when ctx is canceled.
+func statEntries(ctx context.Context, paths []string, opts utils.Options) []data.MyLSFiles {
+	results := make([]data.MyLSFiles, len(paths))
+	found := make([]bool, len(paths))
+
+	jobs := opts.Jobs
+	if jobs == 0 {
+		jobs = defaultJobs
+	}
+	workers := min(jobs, len(paths))
+
+	if workers <= 1 {
+		for i, path := range paths {
+			if ctx.Err() != nil {
+				break
+			}
+			results[i], found[i] = statEntry(path, opts)
+		}
+	} else {
+		work := make(chan int)
+		done := make(chan struct{})
+
+		// Each worker writes only to the slots of the indexes it receives,
+		// so the results need no locking.
+		for range workers {
+			go func() {
+				for i := range work {
+					if ctx.Err() == nil {
+						results[i], found[i] = statEntry(paths[i], opts)
+					}
+				}
+				done <- struct{}{}
+			}()
+		}
+		for i := range paths {
+			work <- i
+		}
+		close(work)
+		for range workers {
+			<-done
+		}
+	}
+
+	files := results[:0]
+	for i, ok := range found {
+		if ok {
+			files = append(files, results[i])
+		}
+	}
+	return files
+}
+
+// statEntry returns the attributes of the directory entry at path. With -L
+// a symbolic link is shown as the file it points to, unless it is broken.
+func statEntry(path string, opts utils.Options) (data.MyLSFiles, bool) {
+	info, err := Lstat(path)
+	if err != nil {
+		return data.MyLSFiles{}, false
+	}
+	if info.Mode()&os.ModeSymlink != 0 && opts.SymlinkPolicy() == utils.DerefAlways {
+		if target, err := Stat(path); err == nil {
+			info = target
+		}
+	}
+	return newEntry(path, info, false, opts), true
+}
@@ -0,0 +1,66 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"ls/utils"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// makeFiles creates n empty files in a temporary directory and returns
+// their paths.
+func makeFiles(b *testing.B, n int) []string {
+	b.Helper()
+	dir := b.TempDir()
+	paths := make([]string, n)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("file%04d", i))
+		if err := os.WriteFile(paths[i], nil, 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return paths
+}
+
+// benchmarkJobs runs statEntries over paths with different numbers of
+// workers, as in `myls -l --jobs N`.
+func benchmarkJobs(b *testing.B, paths []string) {
+	for _, jobs := range []int{1, 2, 4, 8, 16} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			opts := utils.Options{Long: true, Jobs: jobs}
+			for range b.N {
+				if files := statEntries(context.Background(), paths, opts); len(files) != len(paths) {
+					b.Fatalf("got %d entries, want %d", len(files), len(paths))
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkStatEntries measures the lookup of the entries of one large
+// directory on a local disk, where the inode cache answers every call.
+func BenchmarkStatEntries(b *testing.B) {
+	benchmarkJobs(b, makeFiles(b, 5000))
+}
+
+// BenchmarkStatEntriesSlowDisk measures the same lookups when every call
+// waits for a round trip to a server, as on NFS, by delaying the Lstat and
+// Stat hooks.
+func BenchmarkStatEntriesSlowDisk(b *testing.B) {
+	const latency = 200 * time.Microsecond
+	lstat, stat := Lstat, Stat
+	defer func() { Lstat, Stat = lstat, stat }()
+	Lstat = func(name string) (os.FileInfo, error) {
+		time.Sleep(latency)
+		return lstat(name)
+	}
+	Stat = func(name string) (os.FileInfo, error) {
+		time.Sleep(latency)
+		return stat(name)
+	}
+
+	benchmarkJobs(b, makeFiles(b, 500))
+}
@@ -0,0 +1,13 @@
+//go:build !(darwin || freebsd || netbsd)
+
+package logic
+
+import (
+	"syscall"
+	"time"
+)
+
+// statTimes returns the access and status change times of stat.
+func statTimes(stat *syscall.Stat_t) (atime, ctime time.Time) {
+	return time.Unix(stat.Atim.Unix()), time.Unix(stat.Ctim.Unix())
+}
@@ -0,0 +1,14 @@
+//go:build darwin || freebsd || netbsd
+
+package logic
+
+import (
+	"syscall"
+	"time"
+)
+
+// statTimes returns the access and status change times of stat, which
+// these systems name Atimespec and Ctimespec.
+func statTimes(stat *syscall.Stat_t) (atime, ctime time.Time) {
+	return time.Unix(stat.Atimespec.Unix()), time.Unix(stat.Ctimespec.Unix())
+}
@@ -0,0 +1,135 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"ls/colorpkg"
+	"ls/data"
+	"ls/utils"
+)
+
+// treeLines are the connectors drawn in front of the names by --tree.
+type treeLines struct {
+	branch   string // an entry followed by others
+	last     string // the last entry of a directory
+	vertical string // the indentation below an entry followed by others
+	space    string // the indentation below the last entry
+}
+
+var (
+	unicodeTree = treeLines{branch: "├── ", last: "└── ", vertical: "│   ", space: "    "}
+	asciiTree   = treeLines{branch: "|-- ", last: "`-- ", vertical: "|   ", space: "    "}
+)
+
+// treePrinter prints the result of List as a tree and counts what it prints.
+type treePrinter struct {
+	opts   utils.Options
+	colors *colorpkg.Palette
+	lines  treeLines
+	format LongFormat // the columns of -l, measured over the whole tree
+	dirs   int
+	files  int
+}
+
+// printTree prints entries with their listed contents as one tree each,
+// followed by the number of directories and files below them. With -l the
+// long format columns come before the connectors. Directories that could
+// not be read are reported on stderr, and the exit status is returned.
+func printTree(entries []data.MyLSFiles, opts utils.Options, colors *colorpkg.Palette) int {
+	p := treePrinter{opts: opts, colors: colors, lines: unicodeTree}
+	if opts.Tree == utils.TreeASCII {
+		p.lines = asciiTree
+	}
+	if opts.Long {
+		p.format = NewLongFormat(treeFiles(entries), opts, colors)
+		// The connectors already put the names out of line.
+		p.format.AlignNames = false
+	}
+
+	status := utils.ExitOK
+	for _, entry := range entries {
+		p.printLine(entry, "")
+		if !entry.Listed {
+			p.files++
+			continue
+		}
+		status = max(status, p.printContents(entry, "", true))
+	}
+
+	fmt.Printf("\n%s, %s\n", plural(p.dirs, "directory", "directories"), plural(p.files, "file", "files"))
+	return status
+}
+
+// printContents prints the children of dir, each line starting with
+// prefix, the indentation drawn for the levels above.
+func (p *treePrinter) printContents(dir data.MyLSFiles, prefix string, top bool) int {
+	if dir.Err != nil {
+		return reportDirError(dir, top && !errors.Is(dir.Err, ErrAlreadyListed))
+	}
+
+	status := utils.ExitOK
+	children := treeChildren(dir)
+	for i, child := range children {
+		connector, indent := p.lines.branch, p.lines.vertical
+		if i == len(children)-1 {
+			connector, indent = p.lines.last, p.lines.space
+		}
+
+		p.printLine(child, prefix+connector)
+		if child.IsDir {
+			p.dirs++
+		} else {
+			p.files++
+		}
+		if child.Listed {
+			status = max(status, p.printContents(child, prefix+indent, false))
+		}
+	}
+	return status
+}
+
+// printLine prints the line of file, its name after the connectors.
+func (p *treePrinter) printLine(file data.MyLSFiles, connectors string) {
+	if p.opts.Long {
+		fmt.Println(formatLongColumns(file, p.format) + connectors + formatLongName(file, p.format))
+		return
+	}
+
+	name := FormatFileNames(file.Name, p.opts)
+	if p.colors != nil {
+		name = p.colors.Paint(p.colors.Color(file), name)
+	}
+	fmt.Println(connectors + name + Indicator(file, p.opts.Indicator))
+}
+
+// treeChildren returns the contents of dir shown in the tree: the "." and
+// ".." entries of -a are left out, as they are not part of the hierarchy.
+func treeChildren(dir data.MyLSFiles) []data.MyLSFiles {
+	children := make([]data.MyLSFiles, 0, len(dir.Children))
+	for _, child := range dir.Children {
+		if child.Name != "." && child.Name != ".." {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// treeFiles returns every file the tree of entries shows.
+func treeFiles(entries []data.MyLSFiles) []data.MyLSFiles {
+	var files []data.MyLSFiles
+	for _, entry := range entries {
+		files = append(files, entry)
+		if entry.Listed {
+			files = append(files, treeFiles(treeChildren(entry))...)
+		}
+	}
+	return files
+}
+
+// plural returns count followed by the singular or plural noun.
+func plural(count int, singular, pluralForm string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, singular)
+	}
+	return fmt.Sprintf("%d %s", count, pluralForm)
+}
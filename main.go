@@ -1,17 +1,14 @@
 package main
 
 import (
+	"context"
 	"ls/logic"
 	"ls/utils"
+	"os"
 )
 
 func main() {
-	paths, lFlag, RFlag, aFlag, rFlag, tFlag := utils.Args()
+	paths, opts := utils.Args()
 
-	if len(paths) == 0 {
-		paths = []string{"."}
-	} else if len(paths) == 1 {
-		paths = []string{paths[0]}
-	}
-	logic.ProcessPaths(paths, lFlag, RFlag, aFlag, rFlag, tFlag)
+	os.Exit(logic.Print(context.Background(), paths, opts))
 }
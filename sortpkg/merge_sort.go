@@ -0,0 +1,61 @@
+package sortpkg
+
+// insertionRun is the length of the runs sorted by insertion sort before
+// merging; short runs are faster to sort that way than to merge.
+const insertionRun = 16
+
+// mergeSort sorts s in place with a stable, O(n log n) bottom-up merge sort.
+// cmp returns a negative number when a sorts before b, a positive number when
+// it sorts after b, and zero when their order must be kept. It is given
+// pointers so that large elements are not copied on every comparison.
+func mergeSort[T any](s []T, cmp func(a, b *T) int) {
+	n := len(s)
+
+	for start := 0; start < n; start += insertionRun {
+		insertionSort(s[start:min(start+insertionRun, n)], cmp)
+	}
+	if n <= insertionRun {
+		return
+	}
+
+	src, dst := s, make([]T, n)
+	for width := insertionRun; width < n; width *= 2 {
+		for lo := 0; lo < n; lo += 2 * width {
+			mid := min(lo+width, n)
+			hi := min(lo+2*width, n)
+			merge(dst[lo:hi], src[lo:mid], src[mid:hi], cmp)
+		}
+		src, dst = dst, src
+	}
+
+	// After an odd number of passes the result is in the scratch buffer.
+	if &src[0] != &s[0] {
+		copy(s, src)
+	}
+}
+
+func insertionSort[T any](s []T, cmp func(a, b *T) int) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && cmp(&s[j-1], &s[j]) > 0; j-- {
+			s[j-1], s[j] = s[j], s[j-1]
+		}
+	}
+}
+
+// merge merges the sorted halves left and right into dst, taking from left on
+// ties so that equal elements keep their order.
+func merge[T any](dst, left, right []T, cmp func(a, b *T) int) {
+	i, j, k := 0, 0, 0
+	for i < len(left) && j < len(right) {
+		if cmp(&left[i], &right[j]) <= 0 {
+			dst[k] = left[i]
+			i++
+		} else {
+			dst[k] = right[j]
+			j++
+		}
+		k++
+	}
+	k += copy(dst[k:], left[i:])
+	copy(dst[k:], right[j:])
+}
@@ -2,30 +2,180 @@ package sortpkg
 
 import (
 	"ls/data"
+	"ls/utils"
+	"math"
 	"os"
 	"strings"
 )
 
-func SortFiles(files *[]data.MyLSFiles, tFlag, rFlag bool) {
-	var caseSensitive bool
-
-	if tFlag {
-		sortByTime(*files, caseSensitive)
-	} else {
-		caseSensitive = isCaseSensitiveSort()
-		if caseSensitive {
-			sortByNameCaseSensitive(*files)
-		} else {
-			sortByName(*files)
-		}
+// Key is one criterion the entries can be ordered by.
+type Key int
+
+const (
+	ByName           Key = iota // name, following the collation of the locale
+	ByTimeDesc                  // modification time, newest first
+	ByAccessTimeDesc            // access time, newest first
+	ByChangeTimeDesc            // status change time, newest first
+	ByBirthTimeDesc             // birth time, newest first; unknown ones last
+	BySizeDesc                  // size, largest first
+	ByExtension                 // extension, following the collation of the locale
+	ByVersion                   // name, with the numbers in it compared by value
+	ByWidth                     // length of the name, shortest first
+)
+
+// sortRecord holds the values the keys compare for one entry. They are
+// computed once before sorting instead of on every comparison.
+type sortRecord struct {
+	index int    // position of the entry in the unsorted slice
+	name  string // name as compared under the current locale
+	raw   string // name as is, to break ties between equal collation keys
+	mtime int64  // modification time in nanoseconds
+	atime int64  // access time in nanoseconds
+	ctime int64  // status change time in nanoseconds
+	btime int64  // birth time in nanoseconds, math.MinInt64 when unknown
+	size  int64  // size in bytes
+	ext   string // extension as compared under the current locale
+	width int    // width of the name in terminal columns
+}
 
+// SortFiles orders the files for the sort mode of opts, breaking ties by
+// name, and reverses the result for `-r`. Sorting by time uses the timestamp
+// selected by opts.Time. With utils.SortNone the files are left in the order
+// they were read, even with `-r`.
+func SortFiles(files *[]data.MyLSFiles, opts utils.Options) {
+	switch opts.Sort {
+	case utils.SortNone:
+		return
+	case utils.SortByTime:
+		SortBy(*files, timeKey(opts.Time), ByName)
+	case utils.SortBySize:
+		SortBy(*files, BySizeDesc, ByName)
+	case utils.SortByExtension:
+		SortBy(*files, ByExtension, ByName)
+	case utils.SortByVersion:
+		SortBy(*files, ByVersion, ByName)
+	case utils.SortByWidth:
+		SortBy(*files, ByWidth, ByName)
+	default:
+		SortBy(*files, ByName)
 	}
 
-	if rFlag {
+	if opts.Reverse {
 		reverseFiles(*files)
 	}
 }
 
+// timeKey returns the key sorting by the timestamp field, newest first.
+func timeKey(field utils.TimeField) Key {
+	switch field {
+	case utils.TimeAccess:
+		return ByAccessTimeDesc
+	case utils.TimeChange:
+		return ByChangeTimeDesc
+	case utils.TimeBirth:
+		return ByBirthTimeDesc
+	default:
+		return ByTimeDesc
+	}
+}
+
+// SortBy sorts files stably by the chain of keys: each key only decides
+// between entries that all the previous keys consider equal.
+func SortBy(files []data.MyLSFiles, keys ...Key) {
+	if len(files) < 2 {
+		return
+	}
+
+	caseSensitive := isCaseSensitiveSort()
+	records := make([]sortRecord, len(files))
+	for i := range files {
+		records[i] = newSortRecord(i, &files[i], caseSensitive)
+	}
+
+	mergeSort(records, func(a, b *sortRecord) int {
+		for _, key := range keys {
+			if c := compareKey(key, a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+
+	sorted := make([]data.MyLSFiles, len(files))
+	for i, record := range records {
+		sorted[i] = files[record.index]
+	}
+	copy(files, sorted)
+}
+
+func newSortRecord(index int, file *data.MyLSFiles, caseSensitive bool) sortRecord {
+	name := file.Name
+	ext := utils.Ext(file.Name)
+	if !caseSensitive {
+		name = normalizeASCII(name)
+		ext = normalizeASCII(ext)
+	}
+	return sortRecord{
+		index: index,
+		name:  name,
+		raw:   file.Name,
+		mtime: file.ModTime.UnixNano(),
+		atime: file.AccessTime.UnixNano(),
+		ctime: file.ChangeTime.UnixNano(),
+		btime: birthNano(file),
+		size:  file.Size,
+		ext:   ext,
+		width: utils.DisplayWidth(file.Name),
+	}
+}
+
+// compareKey compares two records on a single key.
+func compareKey(key Key, a, b *sortRecord) int {
+	switch key {
+	case ByName:
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.raw, b.raw)
+	case ByTimeDesc:
+		return compareInt64(b.mtime, a.mtime)
+	case ByAccessTimeDesc:
+		return compareInt64(b.atime, a.atime)
+	case ByChangeTimeDesc:
+		return compareInt64(b.ctime, a.ctime)
+	case ByBirthTimeDesc:
+		return compareInt64(b.btime, a.btime)
+	case BySizeDesc:
+		return compareInt64(b.size, a.size)
+	case ByExtension:
+		return strings.Compare(a.ext, b.ext)
+	case ByVersion:
+		return compareVersions(a.raw, b.raw)
+	case ByWidth:
+		return compareInt64(int64(a.width), int64(b.width))
+	}
+	return 0
+}
+
+// birthNano returns the birth time of file in nanoseconds, or math.MinInt64
+// when it is not known so those files sort as the oldest.
+func birthNano(file *data.MyLSFiles) int64 {
+	if file.BirthTime.IsZero() {
+		return math.MinInt64
+	}
+	return file.BirthTime.UnixNano()
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
 // normalizeASCII keeps printable ASCII characters and converts A-Z to a-z.
 func normalizeASCII(name string) string {
 	var b strings.Builder
@@ -58,51 +208,6 @@ func normalizeASCII(name string) string {
 	return b.String()
 }
 
-func sortByName(files []data.MyLSFiles) {
-	n := len(files)
-	for i := 0; i < n-1; i++ {
-		for j := 0; j < n-i-1; j++ {
-			if normalizeASCII(files[j].Name) > normalizeASCII(files[j+1].Name) {
-				files[j], files[j+1] = files[j+1], files[j]
-			}
-		}
-	}
-}
-
-func sortByNameCaseSensitive(files []data.MyLSFiles) {
-	n := len(files)
-	for i := 0; i < n-1; i++ {
-		for j := 0; j < n-i-1; j++ {
-			if files[j].Name > files[j+1].Name {
-				files[j], files[j+1] = files[j+1], files[j]
-			}
-		}
-	}
-}
-
-// sortByTime sorts the given slice of MyLSFiles by modification time in descending order,
-// so that the most recently modified files appear first.
-// It also uses a bubble sort algorithm for ordering.
-func sortByTime(files []data.MyLSFiles, caseSensitive bool) {
-	n := len(files)
-	for i := 0; i < n-1; i++ {
-		for j := 0; j < n-i-1; j++ {
-			if files[j].ModTime.Before(files[j+1].ModTime) {
-				files[j], files[j+1] = files[j+1], files[j]
-			} else if files[j].ModTime.Equal(files[j+1].ModTime) {
-				a, b := files[j].Name, files[j+1].Name
-				if !caseSensitive {
-					a = normalizeASCII(a)
-					b = normalizeASCII(b)
-				}
-				if a > b {
-					files[j], files[j+1] = files[j+1], files[j]
-				}
-			}
-		}
-	}
-}
-
 // reverseFiles reverses the order of the given slice of MyLSFiles.
 // This is useful when the `-r` flag is enabled to display results in reverse order.
 func reverseFiles(files []data.MyLSFiles) {
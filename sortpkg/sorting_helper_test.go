@@ -0,0 +1,56 @@
+package sortpkg
+
+import (
+	"fmt"
+	"ls/data"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// syntheticFiles returns n entries with names, extensions, sizes and times
+// drawn from small ranges, so that the first keys of a chain often tie and
+// the later ones are compared too.
+func syntheticFiles(n int) []data.MyLSFiles {
+	exts := []string{"", ".go", ".txt", ".tar.gz", ".md", ".Log"}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := rand.New(rand.NewSource(1))
+
+	files := make([]data.MyLSFiles, n)
+	for i := range files {
+		files[i] = data.MyLSFiles{
+			Name:      fmt.Sprintf("File_%d-v%d%s", r.Intn(n), r.Intn(20), exts[r.Intn(len(exts))]),
+			Size:      int64(r.Intn(4096)),
+			ModTime:   base.Add(time.Duration(r.Intn(1000)) * time.Second),
+			BirthTime: base,
+		}
+	}
+	return files
+}
+
+func BenchmarkSortBy(b *testing.B) {
+	chains := []struct {
+		name string
+		keys []Key
+	}{
+		{"name", []Key{ByName}},
+		{"time,name", []Key{ByTimeDesc, ByName}},
+		{"size,ext,name", []Key{BySizeDesc, ByExtension, ByName}},
+		{"birth,version,name", []Key{ByBirthTimeDesc, ByVersion, ByName}},
+	}
+
+	for _, n := range []int{10_000, 100_000, 1_000_000} {
+		unsorted := syntheticFiles(n)
+		files := make([]data.MyLSFiles, n)
+		for _, chain := range chains {
+			b.Run(fmt.Sprintf("%s/%d", chain.name, n), func(b *testing.B) {
+				for range b.N {
+					b.StopTimer()
+					copy(files, unsorted)
+					b.StartTimer()
+					SortBy(files, chain.keys...)
+				}
+			})
+		}
+	}
+}
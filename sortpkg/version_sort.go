@@ -0,0 +1,128 @@
+package sortpkg
+
+// compareVersions compares two names the way `ls -v` (filevercmp of GNU)
+// does: runs of digits are compared by their numeric value and the other
+// characters byte by byte, with letters before the other characters and
+// `~` before everything, even the end of the name. So "file9" comes before
+// "file10" and "v1.9" before "v1.10". "." and ".." come first, then the
+// other hidden names. File suffixes such as ".tar.gz" are only compared when
+// the names are equal without them.
+func compareVersions(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return -1
+	}
+	if b == "" {
+		return 1
+	}
+
+	if a[0] == '.' {
+		if b[0] != '.' {
+			return -1
+		}
+		for _, special := range []string{".", ".."} {
+			switch {
+			case a == special:
+				return -1
+			case b == special:
+				return 1
+			}
+		}
+	} else if b[0] == '.' {
+		return 1
+	}
+
+	aPrefix, bPrefix := suffixStart(a), suffixStart(b)
+	if c := compareVersionParts(a[:aPrefix], b[:bPrefix]); c != 0 {
+		return c
+	}
+	if aPrefix == len(a) && bPrefix == len(b) {
+		return 0
+	}
+	return compareVersionParts(a, b)
+}
+
+// suffixStart returns the length of name without its file suffix: the
+// longest run at the end of the form `(\.[A-Za-z~][A-Za-z0-9~]*)*`. The
+// first character is never part of the suffix.
+func suffixStart(name string) int {
+	prefix := 0
+	for i := 0; i < len(name); {
+		i++
+		prefix = i
+		for i+1 < len(name) && name[i] == '.' && (isAlpha(name[i+1]) || name[i+1] == '~') {
+			for i += 2; i < len(name) && (isAlpha(name[i]) || isDigit(name[i]) || name[i] == '~'); i++ {
+			}
+		}
+	}
+	return prefix
+}
+
+// compareVersionParts compares a and b as alternating runs of non-digits and
+// digits.
+func compareVersionParts(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			ac, bc := versionOrder(a, i), versionOrder(b, j)
+			if ac != bc {
+				return ac - bc
+			}
+			i++
+			j++
+		}
+
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+
+		firstDiff := 0
+		for i < len(a) && j < len(b) && isDigit(a[i]) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		switch {
+		case i < len(a) && isDigit(a[i]):
+			return 1
+		case j < len(b) && isDigit(b[j]):
+			return -1
+		case firstDiff != 0:
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+// versionOrder returns the weight of the character at s[i] outside a run of
+// digits; the end of s weighs 0.
+func versionOrder(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+	switch c := s[i]; {
+	case isDigit(c):
+		return 0
+	case isAlpha(c):
+		return int(c)
+	case c == '~':
+		return -1
+	default:
+		return int(c) + 256
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,151 @@
+package sortpkg
+
+import "testing"
+
+// versionExamples are sorted by compareVersions; they are the examples of
+// the filevercmp tests of gnulib (tests/test-filevercmp.c).
+var versionExamples = []string{
+	"",
+	".",
+	"..",
+	".0",
+	".9",
+	".A",
+	".Z",
+	".a~",
+	".a",
+	".b~",
+	".b",
+	".z",
+	".zz~",
+	".zz",
+	".zz.~1~",
+	".zz.0",
+	".\x01",
+	".\x01.txt",
+	".\x01x",
+	".\x01x\x01",
+	".\x01.0",
+	"0",
+	"9",
+	"A",
+	"Z",
+	"a~",
+	"a",
+	"a.b~",
+	"a.b",
+	"a.bc~",
+	"a.bc",
+	"a+",
+	"a.",
+	"a..a",
+	"a.+",
+	"b~",
+	"b",
+	"gcc-c++-10.fc9.tar.gz",
+	"gcc-c++-10.fc9.tar.gz.~1~",
+	"gcc-c++-10.fc9.tar.gz.~2~",
+	"gcc-c++-10.8.12-0.7rc2.fc9.tar.bz2",
+	"gcc-c++-10.8.12-0.7rc2.fc9.tar.bz2.~1~",
+	"glibc-2-0.1.beta1.fc10.rpm",
+	"glibc-common-5-0.2.beta2.fc9.ebuild",
+	"glibc-common-5-0.2b.deb",
+	"glibc-common-11b.ebuild",
+	"glibc-common-11-0.6rc2.ebuild",
+	"libstdc++-0.5.8.11-0.7rc2.fc10.tar.gz",
+	"libstdc++-4a.fc8.tar.gz",
+	"libstdc++-4.10.4.20040204svn.rpm",
+	"libstdc++-devel-3.fc8.ebuild",
+	"libstdc++-devel-3a.fc9.tar.gz",
+	"libstdc++-devel-8.fc8.deb",
+	"libstdc++-devel-8.6.2-0.4b.fc8",
+	"nss_ldap-1-0.2b.fc9.tar.bz2",
+	"nss_ldap-1-0.6rc2.fc8.tar.gz",
+	"nss_ldap-1.0-0.1a.tar.gz",
+	"nss_ldap-10beta1.fc8.tar.gz",
+	"nss_ldap-10.11.8.6.20040204cvs.fc10.ebuild",
+	"z",
+	"zz~",
+	"zz",
+	"zz.~1~",
+	"zz.0",
+	"zz.0.txt",
+	"\x01",
+	"\x01.txt",
+	"\x01x",
+	"\x01x\x01",
+	"\x01.0",
+	"#\x01.b#",
+	"#.b#",
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func TestCompareVersionsOrder(t *testing.T) {
+	for i, a := range versionExamples {
+		for j, b := range versionExamples {
+			if got, want := sign(compareVersions(a, b)), sign(i-j); got != want {
+				t.Errorf("compareVersions(%q, %q) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+}
+
+// Leading zeros do not count, so these names compare equal; the sort then
+// falls back on the names themselves.
+func TestCompareVersionsEqual(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"a", "a0"},
+		{"a", "a00"},
+		{"a0", "a00"},
+		{"a.b", "a.b0"},
+		{"a.b", "a.b00"},
+		{"a.0", "a.00"},
+		{"file7", "file007"},
+		{"v1.02", "v1.2"},
+		{"x.tar.gz", "x.tar.gz"},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != 0 {
+			t.Errorf("compareVersions(%q, %q) = %d, want 0", tt.a, tt.b, got)
+		}
+		if got := compareVersions(tt.b, tt.a); got != 0 {
+			t.Errorf("compareVersions(%q, %q) = %d, want 0", tt.b, tt.a, got)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"file9", "file10", -1},
+		{"v1.9", "v1.10", -1},
+		{"v1.10", "v1.9.1", 1},
+		{"img2.png", "img10.png", -1},
+		{"a-1.2.tar.gz", "a-1.10.tar", -1},
+		{"a.tar.gz", "a.zip", -1},
+		{"x~", "x", -1},
+		{"x.~1~", "x.~2~", -1},
+		{".hidden", "visible", -1},
+		{"..", ".a", -1},
+		{".", "..", -1},
+	}
+	for _, tt := range tests {
+		if got := sign(compareVersions(tt.a, tt.b)); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := sign(compareVersions(tt.b, tt.a)); got != -tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
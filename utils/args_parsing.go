@@ -1,76 +1,152 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 )
 
-// Args parses command-line arguments and extracts the directory path and flags.
-//
-// It scans through `os.Args`, identifying flags and setting corresponding boolean values:
-//   - `-a` : Includes hidden files.
-//   - `-R` : Enables recursive listing.
-//   - `-t` : Sorts files by modification time.
-//   - `-l` : Enables long listing format with detailed file information.
-//   - `-r` : Reverses the sorting order.
+// errHelp is returned by ParseArgs when --help is given.
+var errHelp = errors.New("help requested")
+
+// Args parses the command-line arguments and extracts the paths and options.
 //
-// If an argument is not a flag (i.e., does not start with `-`), it is treated as the directory path.
+// The recognized options are declared in optionTable; `--help` prints the
+// text generated from it. On an invalid option the error is printed to
+// stderr together with a hint about `--help` and the program exits with
+// ExitSerious.
 //
 // Returns:
-//   - `path` (string): The specified directory path, or an empty string if none is provided (defaults to `.`).
-//   - `lFlag`, `RFlag`, `aFlag`, `rFlag`, `tFlag` (bool): Boolean values representing whether each flag is set.
-func Args() (paths []string, lFlag, RFlag, aFlag, rFlag, tFlag bool) {
+//   - `paths` ([]string): The specified paths, or an empty slice if none is provided (defaults to `.`).
+//   - `opts` (Options): The listing options selected by the flags.
+func Args() (paths []string, opts Options) {
+	paths, opts, err := ParseArgs(os.Args[1:])
+	if errors.Is(err, errHelp) {
+		fmt.Print(helpText())
+		os.Exit(ExitOK)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "myls: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Try './myls --help' for more information.")
+		os.Exit(ExitSerious)
+	}
+	return paths, opts
+}
+
+// ParseArgs parses args the way GNU getopt_long does:
+//
+//   - Short options may be grouped (`-la`); an option that takes an argument
+//     uses the rest of the group or the next argument (`-w80`, `-w 80`).
+//   - Long options accept `--opt=value` and `--opt value`, and may be
+//     abbreviated to any unambiguous prefix (`--rec`).
+//   - Options and paths may be mixed; `--` ends the options and `-` alone
+//     is a path.
+func ParseArgs(args []string) (paths []string, opts Options, err error) {
 	endOfFlags := false
-	flags := "-aRtlr"
 
-	for _, arg := range os.Args[1:] {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
 
-		if arg == "--help" {
-			fmt.Println("Usage: ./myls [OPTION]... [FILE]...")
-			fmt.Println("List information about the FILEs (the current directory by default).")
-			fmt.Println("Options:")
-			fmt.Println("  -a  : Includes hidden files.")
-			fmt.Println("  -R  : Enables recursive listing.")
-			fmt.Println("  -t  : Sorts files by modification time.")
-			fmt.Println("  -l  : Enables long listing format with detailed file information.")
-			fmt.Println("  -r  : Reverses the sorting order.")
-			os.Exit(0)
-		}
+		switch {
+		case endOfFlags || arg == "-" || !strings.HasPrefix(arg, "-"):
+			paths = append(paths, arg)
 
 		// If we encounter the double-dash, stop processing flags.
-		if arg == "--" {
+		case arg == "--":
 			endOfFlags = true
-			continue
-		}
 
-		if !endOfFlags && strings.HasPrefix(arg, "-") && arg != "-" {
-			for _, r := range arg {
-				if !strings.ContainsAny(flags, string(r)) {
-					fmt.Printf("myls: unrecognized option '%s'\n", arg)
-					fmt.Println("Try './myls --help' for more information.")
-					os.Exit(0)
-				}
-			}
-			if strings.Contains(arg, "a") {
-				aFlag = true
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			spec, err := lookupLong(name, arg)
+			if err != nil {
+				return nil, opts, err
 			}
-			if strings.Contains(arg, "R") {
-				RFlag = true
+			if spec.long == "help" {
+				return nil, opts, errHelp
 			}
-			if strings.Contains(arg, "t") {
-				tFlag = true
+			switch {
+			case spec.arg == "" && hasValue:
+				return nil, opts, fmt.Errorf("option '--%s' doesn't allow an argument", spec.long)
+			case spec.arg != "" && !hasValue && !spec.optArg:
+				if i+1 >= len(args) {
+					return nil, opts, fmt.Errorf("option '--%s' requires an argument", spec.long)
+				}
+				i++
+				value = args[i]
 			}
-			if strings.Contains(arg, "l") {
-				lFlag = true
+			if err := spec.apply(&opts, value); err != nil {
+				return nil, opts, err
 			}
-			if strings.Contains(arg, "r") {
-				rFlag = true
+
+		default:
+			for j := 1; j < len(arg); j++ {
+				spec := lookupShort(arg[j])
+				if spec == nil {
+					return nil, opts, fmt.Errorf("invalid option -- '%c'", arg[j])
+				}
+				value := ""
+				if spec.arg != "" {
+					switch {
+					case j+1 < len(arg):
+						value = arg[j+1:]
+					case i+1 < len(args):
+						i++
+						value = args[i]
+					default:
+						return nil, opts, fmt.Errorf("option requires an argument -- '%c'", arg[j])
+					}
+					j = len(arg)
+				}
+				if err := spec.apply(&opts, value); err != nil {
+					return nil, opts, err
+				}
 			}
-		} else {
-			paths = append(paths, arg)
 		}
 	}
 
-	return
+	opts.resolveTimeSort()
+	return paths, opts, nil
+}
+
+// lookupShort returns the option with the given single-letter name, or nil.
+func lookupShort(c byte) *optionSpec {
+	for i := range optionTable {
+		if optionTable[i].short == c {
+			return &optionTable[i]
+		}
+	}
+	return nil
+}
+
+// lookupLong returns the option whose long name is name or, failing an exact
+// match, the only one that starts with name. The errors quote arg, the whole
+// argument, as getopt_long does.
+func lookupLong(name, arg string) (*optionSpec, error) {
+	var matches []*optionSpec
+	for i := range optionTable {
+		spec := &optionTable[i]
+		if spec.long == "" {
+			continue
+		}
+		if spec.long == name {
+			return spec, nil
+		}
+		if name != "" && strings.HasPrefix(spec.long, name) {
+			matches = append(matches, spec)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("unrecognized option '%s'", arg)
+	case 1:
+		return matches[0], nil
+	}
+
+	possibilities := make([]string, len(matches))
+	for i, spec := range matches {
+		possibilities[i] = "'--" + spec.long + "'"
+	}
+	return nil, fmt.Errorf("option '%s' is ambiguous; possibilities: %s", arg, strings.Join(possibilities, " "))
 }
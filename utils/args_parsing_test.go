@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		paths []string
+		check func(Options) bool
+	}{
+		{"nothing", nil, nil, func(o Options) bool { return !o.Long && !o.All }},
+		{"one option", []string{"-l"}, nil, func(o Options) bool { return o.Long }},
+
+		// Short options.
+		{"grouped", []string{"-laR"}, nil, func(o Options) bool { return o.Long && o.All && o.Recursive }},
+		{"argument in the group", []string{"-w80"}, nil, func(o Options) bool { return o.Width == 80 }},
+		{"argument after flags", []string{"-lw80"}, nil, func(o Options) bool { return o.Long && o.Width == 80 }},
+		{"argument in the next word", []string{"-w", "80", "dir"}, []string{"dir"}, func(o Options) bool { return o.Width == 80 }},
+
+		// Long options.
+		{"long", []string{"--all"}, nil, func(o Options) bool { return o.All }},
+		{"long with =", []string{"--width=80"}, nil, func(o Options) bool { return o.Width == 80 }},
+		{"long with the next word", []string{"--width", "80"}, nil, func(o Options) bool { return o.Width == 80 }},
+		{"empty value", []string{"--hide="}, nil, func(o Options) bool { return reflect.DeepEqual(o.Hide, []string{""}) }},
+		{"value with =", []string{"--hide=a=b"}, nil, func(o Options) bool { return reflect.DeepEqual(o.Hide, []string{"a=b"}) }},
+		{"optional argument", []string{"--color=never"}, nil, func(o Options) bool { return o.Color == ColorNever }},
+		{"optional argument left out", []string{"--color", "never"}, []string{"never"}, func(o Options) bool { return o.Color == ColorAlways }},
+		{"prefix", []string{"--recur"}, nil, func(o Options) bool { return o.Recursive }},
+		{"prefix with a value", []string{"--wid=80"}, nil, func(o Options) bool { return o.Width == 80 }},
+		{"exact match over prefix", []string{"--si"}, nil, func(o Options) bool { return o.BlockSize.Human && o.BlockSize.Base == 1000 }},
+		{"exact match, longer ones exist", []string{"--hide=x"}, nil, func(o Options) bool { return len(o.Hide) == 1 }},
+
+		// Paths.
+		{"mixed with paths", []string{"a", "-l", "b"}, []string{"a", "b"}, func(o Options) bool { return o.Long }},
+		{"dash is a path", []string{"-", "-l"}, []string{"-"}, func(o Options) bool { return o.Long }},
+		{"double dash", []string{"-l", "--", "-a", "--all"}, []string{"-a", "--all"}, func(o Options) bool { return o.Long && !o.All }},
+		{"second double dash", []string{"--", "--"}, []string{"--"}, nil},
+		{"last option wins", []string{"-C", "-1"}, nil, func(o Options) bool { return o.Layout == LayoutSingle }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, opts, err := ParseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("ParseArgs(%q): %v", tt.args, err)
+			}
+			if !reflect.DeepEqual(paths, tt.paths) {
+				t.Errorf("ParseArgs(%q) paths = %q, want %q", tt.args, paths, tt.paths)
+			}
+			if tt.check != nil && !tt.check(opts) {
+				t.Errorf("ParseArgs(%q) gives the wrong options: %+v", tt.args, opts)
+			}
+		})
+	}
+}
+
+// The messages are those of GNU ls.
+func TestParseArgsErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-Y"}, "invalid option -- 'Y'"},
+		{[]string{"-lY"}, "invalid option -- 'Y'"},
+		{[]string{"-w"}, "option requires an argument -- 'w'"},
+		{[]string{"-lw"}, "option requires an argument -- 'w'"},
+		{[]string{"-w8l"}, "invalid line width: '8l'"},
+		{[]string{"--bogus"}, "unrecognized option '--bogus'"},
+		{[]string{"--bogus=x"}, "unrecognized option '--bogus=x'"},
+		{[]string{"--=x"}, "unrecognized option '--=x'"},
+		{[]string{"--all=x"}, "option '--all' doesn't allow an argument"},
+		{[]string{"--al=x"}, "option '--al=x' is ambiguous; possibilities: '--all' '--almost-all'"},
+		{[]string{"--width"}, "option '--width' requires an argument"},
+		{[]string{"--widt"}, "option '--width' requires an argument"},
+		{[]string{"--re"}, "option '--re' is ambiguous; possibilities: '--reverse' '--recursive'"},
+		{[]string{"--tim=iso"}, "option '--tim=iso' is ambiguous; possibilities: '--time' '--time-style'"},
+		{[]string{"--width=abc"}, "invalid line width: 'abc'"},
+		{[]string{"--color=sometimes"}, "invalid argument 'sometimes' for '--color'"},
+	}
+	for _, tt := range tests {
+		_, _, err := ParseArgs(tt.args)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("ParseArgs(%q) error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+
+	for _, args := range [][]string{{"--help"}, {"--he"}, {"-l", "--help", "--bogus"}} {
+		if _, _, err := ParseArgs(args); !errors.Is(err, errHelp) {
+			t.Errorf("ParseArgs(%q) error = %v, want errHelp", args, err)
+		}
+	}
+}
+
+// TestArgsExitStatus runs Args in a child process, since it exits: a bad
+// option prints the error and a hint to stderr and exits with ExitSerious.
+func TestArgsExitStatus(t *testing.T) {
+	if args := os.Getenv("MYLS_TEST_ARGS"); args != "" {
+		os.Args = append([]string{"myls"}, strings.Fields(args)...)
+		Args()
+		os.Exit(ExitOK)
+	}
+
+	tests := []struct {
+		args   string
+		status int
+		stderr string
+	}{
+		{"-l", ExitOK, ""},
+		{"--help", ExitOK, ""},
+		{"-Y", ExitSerious, "myls: invalid option -- 'Y'\nTry './myls --help' for more information.\n"},
+		{"--block-size=0", ExitSerious, "myls: invalid --block-size argument '0'\nTry './myls --help' for more information.\n"},
+	}
+	for _, tt := range tests {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestArgsExitStatus$")
+		cmd.Env = append(os.Environ(), "MYLS_TEST_ARGS="+tt.args)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+
+		status := 0
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			status = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if status != tt.status || stderr.String() != tt.stderr {
+			t.Errorf("myls %s: exit status %d, stderr %q, want %d, %q", tt.args, status, stderr.String(), tt.status, tt.stderr)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// BlockSize is the unit sizes are printed in, as set by -h, --si,
+// --block-size or the LS_BLOCK_SIZE and BLOCK_SIZE environment variables.
+// The zero value is the default of ls: file sizes in bytes and the "total"
+// line in kibibytes.
+type BlockSize struct {
+	Unit   uint64 // bytes per printed unit; ignored when Human is set
+	Human  bool   // pick a unit for every number (-h, --si)
+	Base   uint64 // 1024, or 1000 for --si and units like "kB"
+	Suffix string // printed after every number, e.g. "M" for --block-size=M
+}
+
+// sizePrefixes are the letters of the powers of the base, from kilo up.
+const sizePrefixes = "KMGTPEZY"
+
+// ParseBlockSize parses a block size the way GNU ls does: a number with an
+// optional unit (`1K`, `4096`, `1MB`, `2MiB`), a unit alone, which is then
+// printed after every size (`M`, `kB`), or one of the words `human-readable`
+// and `si`. A leading `'` (digit grouping) is accepted and ignored.
+func ParseBlockSize(spec string) (BlockSize, error) {
+	invalid := fmt.Errorf("invalid --block-size argument '%s'", spec)
+	badSuffix := fmt.Errorf("invalid suffix in --block-size argument '%s'", spec)
+	tooLarge := fmt.Errorf("--block-size argument '%s' too large", spec)
+
+	spec = strings.TrimPrefix(spec, "'")
+	switch spec {
+	case "human-readable":
+		return BlockSize{Human: true, Base: 1024}, nil
+	case "si":
+		return BlockSize{Human: true, Base: 1000}, nil
+	case "":
+		return BlockSize{}, invalid
+	}
+
+	digits := 0
+	for digits < len(spec) && spec[digits] >= '0' && spec[digits] <= '9' {
+		digits++
+	}
+
+	number := uint64(1)
+	if digits > 0 {
+		n, err := strconv.ParseUint(spec[:digits], 10, 64)
+		if err != nil {
+			return BlockSize{}, tooLarge
+		}
+		if n == 0 {
+			return BlockSize{}, invalid
+		}
+		number = n
+	}
+
+	unit := spec[digits:]
+	if unit == "" {
+		return BlockSize{Unit: number, Base: 1024}, nil
+	}
+
+	power := strings.IndexByte(sizePrefixes, byte(strings.ToUpper(unit[:1])[0])) + 1
+	if power == 0 && digits == 0 {
+		return BlockSize{}, invalid
+	}
+	if power == 0 {
+		return BlockSize{}, badSuffix
+	}
+
+	base, suffix := uint64(1024), unit[:1]
+	switch unit[1:] {
+	case "":
+		suffix = strings.ToUpper(suffix)
+	case "B":
+		base = 1000
+		suffix = prefixLetter(power, base) + "B"
+	case "iB":
+		suffix = strings.ToUpper(suffix) + "iB"
+	default:
+		return BlockSize{}, badSuffix
+	}
+
+	size := number
+	for range power {
+		if size > ^uint64(0)/base {
+			return BlockSize{}, tooLarge
+		}
+		size *= base
+	}
+
+	bs := BlockSize{Unit: size, Base: base}
+	if digits == 0 {
+		bs.Suffix = suffix
+	}
+	return bs, nil
+}
+
+// blockSizeFromEnv returns the block size set by LS_BLOCK_SIZE or, when it
+// is not set, BLOCK_SIZE. An invalid value is ignored like GNU ls does,
+// without falling back to BLOCK_SIZE.
+func blockSizeFromEnv() (BlockSize, bool) {
+	for _, name := range []string{"LS_BLOCK_SIZE", "BLOCK_SIZE"} {
+		if spec := os.Getenv(name); spec != "" {
+			bs, err := ParseBlockSize(spec)
+			return bs, err == nil
+		}
+	}
+	return BlockSize{}, false
+}
+
+// IsDefault reports whether bs is the zero value, i.e. no unit was chosen.
+func (bs BlockSize) IsDefault() bool {
+	return bs == BlockSize{}
+}
+
+// FormatSize formats a file size given in bytes, for the size column.
+func (bs BlockSize) FormatSize(bytes uint64) string {
+	if bs.IsDefault() {
+		return strconv.FormatUint(bytes, 10)
+	}
+	return bs.format(bytes)
+}
+
+// FormatTotal formats the space used by a directory, given in bytes, for
+// the "total" line.
+func (bs BlockSize) FormatTotal(bytes uint64) string {
+	if bs.IsDefault() {
+		return strconv.FormatUint(ceilDiv(bytes, 1024), 10)
+	}
+	return bs.format(bytes)
+}
+
+func (bs BlockSize) format(bytes uint64) string {
+	if !bs.Human {
+		return strconv.FormatUint(ceilDiv(bytes, bs.Unit), 10) + bs.Suffix
+	}
+	return humanSize(bytes, bs.Base)
+}
+
+// humanSize formats bytes with the largest power of base that keeps the
+// number below base, rounding up like GNU ls: with one decimal below 10
+// ("1.5K", "9.9M") and as a whole number from 10 up ("10K", "512M").
+func humanSize(bytes, base uint64) string {
+	if bytes < base {
+		return strconv.FormatUint(bytes, 10)
+	}
+
+	power := 0
+	divisor := uint64(1)
+	for bytes/divisor >= base && power < len(sizePrefixes) {
+		divisor *= base
+		power++
+	}
+
+	for {
+		q, r := bytes/divisor, bytes%divisor
+		if q < 10 {
+			tenths := q*10 + ceilDiv(r*10, divisor)
+			if tenths < 100 {
+				return fmt.Sprintf("%d.%d%s", tenths/10, tenths%10, prefixLetter(power, base))
+			}
+			q, r = 10, 0
+		}
+
+		amount := q
+		if r > 0 {
+			amount++
+		}
+		if amount < base || power == len(sizePrefixes) {
+			return strconv.FormatUint(amount, 10) + prefixLetter(power, base)
+		}
+
+		// Rounding up reached the base: go to the next power ("1.0M").
+		divisor *= base
+		power++
+	}
+}
+
+// prefixLetter returns the letter of the given power of base; kilo is a
+// lowercase "k" for powers of 1000, as in GNU ls.
+func prefixLetter(power int, base uint64) string {
+	if power == 1 && base == 1000 {
+		return "k"
+	}
+	return sizePrefixes[power-1 : power]
+}
+
+func ceilDiv(a, b uint64) uint64 {
+	if a%b == 0 {
+		return a / b
+	}
+	return a/b + 1
+}
@@ -0,0 +1,186 @@
+package utils
+
+import "testing"
+
+func TestParseBlockSize(t *testing.T) {
+	tests := []struct {
+		spec string
+		want BlockSize
+	}{
+		{"human-readable", BlockSize{Human: true, Base: 1024}},
+		{"si", BlockSize{Human: true, Base: 1000}},
+		{"1", BlockSize{Unit: 1, Base: 1024}},
+		{"512", BlockSize{Unit: 512, Base: 1024}},
+		{"'1024", BlockSize{Unit: 1024, Base: 1024}},
+
+		// A unit alone is printed after the sizes; with a number it is not.
+		{"K", BlockSize{Unit: 1024, Base: 1024, Suffix: "K"}},
+		{"k", BlockSize{Unit: 1024, Base: 1024, Suffix: "K"}},
+		{"1K", BlockSize{Unit: 1024, Base: 1024}},
+		{"2M", BlockSize{Unit: 2 << 20, Base: 1024}},
+		{"G", BlockSize{Unit: 1 << 30, Base: 1024, Suffix: "G"}},
+		{"E", BlockSize{Unit: 1 << 60, Base: 1024, Suffix: "E"}},
+
+		// "B" makes the unit a power of 1000, "iB" a power of 1024.
+		{"KB", BlockSize{Unit: 1000, Base: 1000, Suffix: "kB"}},
+		{"kB", BlockSize{Unit: 1000, Base: 1000, Suffix: "kB"}},
+		{"MB", BlockSize{Unit: 1000000, Base: 1000, Suffix: "MB"}},
+		{"3MB", BlockSize{Unit: 3000000, Base: 1000}},
+		{"KiB", BlockSize{Unit: 1024, Base: 1024, Suffix: "KiB"}},
+		{"kiB", BlockSize{Unit: 1024, Base: 1024, Suffix: "KiB"}},
+		{"MiB", BlockSize{Unit: 1 << 20, Base: 1024, Suffix: "MiB"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseBlockSize(tt.spec)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseBlockSize(%q) = %+v, %v, want %+v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+// The messages are those of GNU ls.
+func TestParseBlockSizeErrors(t *testing.T) {
+	tests := []struct{ spec, want string }{
+		{"", "invalid --block-size argument ''"},
+		{"0", "invalid --block-size argument '0'"},
+		{"0K", "invalid --block-size argument '0K'"},
+		{"-1", "invalid --block-size argument '-1'"},
+		{"X", "invalid --block-size argument 'X'"},
+		{"1X", "invalid suffix in --block-size argument '1X'"},
+		{"1KX", "invalid suffix in --block-size argument '1KX'"},
+		{"K1", "invalid suffix in --block-size argument 'K1'"},
+		{"KIB", "invalid suffix in --block-size argument 'KIB'"},
+		{"1.5K", "invalid suffix in --block-size argument '1.5K'"},
+		{"99999999999999999999", "--block-size argument '99999999999999999999' too large"},
+		{"16E", "--block-size argument '16E' too large"},
+		{"Z", "--block-size argument 'Z' too large"},
+		{"1Y", "--block-size argument '1Y' too large"},
+	}
+	for _, tt := range tests {
+		_, err := ParseBlockSize(tt.spec)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("ParseBlockSize(%q) error = %v, want %q", tt.spec, err, tt.want)
+		}
+	}
+}
+
+// The expected sizes are those GNU ls -l prints for files of these sizes.
+func TestFormatSize(t *testing.T) {
+	human := BlockSize{Human: true, Base: 1024}
+	si := BlockSize{Human: true, Base: 1000}
+	tests := []struct {
+		bs    BlockSize
+		bytes uint64
+		want  string
+	}{
+		{BlockSize{}, 1049000, "1049000"},
+
+		{human, 0, "0"},
+		{human, 1023, "1023"},
+		{human, 1024, "1.0K"},
+		{human, 1025, "1.1K"},
+		{human, 1536, "1.5K"},
+		{human, 9999, "9.8K"},
+		{human, 10239, "10K"},
+		{human, 10241, "11K"},
+		{human, 999999, "977K"},
+		{human, 1048575, "1.0M"},
+		{human, 1049000, "1.1M"},
+		{human, 10485760, "10M"},
+
+		{si, 999, "999"},
+		{si, 1000, "1.0k"},
+		{si, 1001, "1.1k"},
+		{si, 9999, "10k"},
+		{si, 99999, "100k"},
+		{si, 999999, "1.0M"},
+		{si, 10485760, "11M"},
+
+		// Fixed units round up.
+		{BlockSize{Unit: 1024, Base: 1024}, 0, "0"},
+		{BlockSize{Unit: 1024, Base: 1024}, 1, "1"},
+		{BlockSize{Unit: 1024, Base: 1024}, 1025, "2"},
+		{BlockSize{Unit: 1024, Base: 1024, Suffix: "K"}, 10241, "11K"},
+		{BlockSize{Unit: 1000, Base: 1000, Suffix: "kB"}, 1023, "2kB"},
+		{BlockSize{Unit: 512, Base: 1024}, 999999, "1954"},
+		{BlockSize{Unit: 1 << 20, Base: 1024, Suffix: "M"}, 1049000, "2M"},
+	}
+	for _, tt := range tests {
+		if got := tt.bs.FormatSize(tt.bytes); got != tt.want {
+			t.Errorf("%+v.FormatSize(%d) = %q, want %q", tt.bs, tt.bytes, got, tt.want)
+		}
+	}
+}
+
+// Without a unit, the "total" line counts kibibytes.
+func TestFormatTotal(t *testing.T) {
+	tests := []struct {
+		bytes uint64
+		want  string
+	}{
+		{0, "0"},
+		{1, "1"},
+		{4096, "4"},
+		{4097, "5"},
+	}
+	for _, tt := range tests {
+		if got := (BlockSize{}).FormatTotal(tt.bytes); got != tt.want {
+			t.Errorf("FormatTotal(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestBlockSizeFromEnv(t *testing.T) {
+	tests := []struct {
+		ls, block string
+		want      BlockSize
+		ok        bool
+	}{
+		{"", "", BlockSize{}, false},
+		{"", "K", BlockSize{Unit: 1024, Base: 1024, Suffix: "K"}, true},
+		{"si", "K", BlockSize{Human: true, Base: 1000}, true},
+		{"1X", "K", BlockSize{}, false},
+		{"", "1X", BlockSize{}, false},
+	}
+	for _, tt := range tests {
+		t.Setenv("LS_BLOCK_SIZE", tt.ls)
+		t.Setenv("BLOCK_SIZE", tt.block)
+		if got, ok := blockSizeFromEnv(); got != tt.want || ok != tt.ok {
+			t.Errorf("LS_BLOCK_SIZE=%q BLOCK_SIZE=%q: got %+v, %v, want %+v, %v", tt.ls, tt.block, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// The last of -h, --si and --block-size wins, and any of them hides the
+// environment.
+func TestBlockSizeOptions(t *testing.T) {
+	t.Setenv("LS_BLOCK_SIZE", "")
+	t.Setenv("BLOCK_SIZE", "K")
+	tests := []struct {
+		args []string
+		want BlockSize
+	}{
+		{nil, BlockSize{Unit: 1024, Base: 1024, Suffix: "K"}},
+		{[]string{"-h"}, BlockSize{Human: true, Base: 1024}},
+		{[]string{"--si"}, BlockSize{Human: true, Base: 1000}},
+		{[]string{"--block-size=M"}, BlockSize{Unit: 1 << 20, Base: 1024, Suffix: "M"}},
+		{[]string{"--block-size", "1K"}, BlockSize{Unit: 1024, Base: 1024}},
+		{[]string{"-h", "--block-size=1K"}, BlockSize{Unit: 1024, Base: 1024}},
+		{[]string{"--block-size=1K", "-h"}, BlockSize{Human: true, Base: 1024}},
+		{[]string{"--si", "-h"}, BlockSize{Human: true, Base: 1024}},
+	}
+	for _, tt := range tests {
+		_, opts, err := ParseArgs(tt.args)
+		if err != nil {
+			t.Errorf("ParseArgs(%q): %v", tt.args, err)
+			continue
+		}
+		if got := ResolveOutput(opts).BlockSize; got != tt.want {
+			t.Errorf("ParseArgs(%q) block size = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+
+	if _, _, err := ParseArgs([]string{"--block-size=0"}); err == nil {
+		t.Errorf("ParseArgs(--block-size=0) succeeded, want an error")
+	}
+}
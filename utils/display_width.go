@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// runeRange is an inclusive range of code points.
+type runeRange struct {
+	lo, hi rune
+}
+
+// wideRanges are the code points a terminal shows in two columns: the East
+// Asian Wide and Fullwidth characters of Unicode 15, which include the
+// emoji with an emoji presentation.
+var wideRanges = []runeRange{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18CFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F251}, {0x1F260, 0x1F265},
+	{0x1F300, 0x1F320}, {0x1F32D, 0x1F335}, {0x1F337, 0x1F37C}, {0x1F37E, 0x1F393},
+	{0x1F3A0, 0x1F3CA}, {0x1F3CF, 0x1F3D3}, {0x1F3E0, 0x1F3F0}, {0x1F3F4, 0x1F3F4},
+	{0x1F3F8, 0x1F43E}, {0x1F440, 0x1F440}, {0x1F442, 0x1F4FC}, {0x1F4FF, 0x1F53D},
+	{0x1F54B, 0x1F54E}, {0x1F550, 0x1F567}, {0x1F57A, 0x1F57A}, {0x1F595, 0x1F596},
+	{0x1F5A4, 0x1F5A4}, {0x1F5FB, 0x1F64F}, {0x1F680, 0x1F6C5}, {0x1F6CC, 0x1F6CC},
+	{0x1F6D0, 0x1F6D2}, {0x1F6D5, 0x1F6D7}, {0x1F6DC, 0x1F6DF}, {0x1F6EB, 0x1F6EC},
+	{0x1F6F4, 0x1F6FC}, {0x1F7E0, 0x1F7EB}, {0x1F7F0, 0x1F7F0}, {0x1F90C, 0x1F93A},
+	{0x1F93C, 0x1F945}, {0x1F947, 0x1F9FF}, {0x1FA70, 0x1FAFF}, {0x20000, 0x2FFFD},
+	{0x30000, 0x3FFFD},
+}
+
+const (
+	softHyphen      = 0x00AD
+	zeroWidthJoiner = 0x200D
+	emojiSelector   = 0xFE0F  // variation selector 16, the emoji presentation
+	regionalFirst   = 0x1F1E6 // regional indicator A; two of them make a flag
+	regionalLast    = 0x1F1FF
+	modifierFirst   = 0x1F3FB // emoji skin tone modifiers
+	modifierLast    = 0x1F3FF
+)
+
+// DisplayWidth returns the number of terminal columns s takes. It counts
+// the East Asian wide characters and emoji as two columns and the combining
+// marks, format characters (such as the soft hyphen) and control characters
+// as none, and it keeps grapheme clusters together: a character joined by a
+// zero-width joiner, a skin tone modifier and the second regional indicator
+// of a flag add no width of their own. A variation selector 16 asks for the
+// emoji presentation of the character before it, two columns wide, as in
+// "❤️". Invalid UTF-8 bytes take one column each.
+func DisplayWidth(s string) int {
+	if isASCIIPrintable(s) {
+		return len(s)
+	}
+
+	width := 0
+	joined := false   // the previous character was a zero-width joiner
+	openFlag := false // the previous character was an unpaired regional indicator
+	last := 0         // width of the last character that took columns
+	for _, r := range s {
+		w := runeWidth(r)
+
+		switch {
+		case joined:
+			w = 0
+		case r == emojiSelector:
+			if last == 1 {
+				w, last = 1, 2
+			}
+		case r >= regionalFirst && r <= regionalLast:
+			if openFlag {
+				w = 0
+			}
+			openFlag = !openFlag
+		case r >= modifierFirst && r <= modifierLast && width > 0:
+			w = 0
+		}
+		if r < regionalFirst || r > regionalLast {
+			openFlag = false
+		}
+		joined = r == zeroWidthJoiner
+		if w > 0 && r != emojiSelector {
+			last = w
+		}
+
+		width += w
+	}
+	return width
+}
+
+// PadRight pads s with spaces to width display columns.
+func PadRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-DisplayWidth(s), 0))
+}
+
+// PadLeft pads s on the left with spaces to width display columns.
+func PadLeft(s string, width int) string {
+	return strings.Repeat(" ", max(width-DisplayWidth(s), 0)) + s
+}
+
+// runeWidth returns the width of a single character.
+func runeWidth(r rune) int {
+	switch {
+	case r == utf8.RuneError:
+		return 1
+	case r < 0x20 || (r >= 0x7F && r < 0xA0) || r == softHyphen:
+		return 0
+	case r < 0x300:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0x1160 && r <= 0x11FF, r >= 0xD7B0 && r <= 0xD7FF:
+		// Hangul medial vowels and final consonants join the syllable.
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// isWide reports whether r is in wideRanges.
+func isWide(r rune) bool {
+	lo, hi := 0, len(wideRanges)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch {
+		case r < wideRanges[mid].lo:
+			hi = mid
+		case r > wideRanges[mid].hi:
+			lo = mid + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// isASCIIPrintable reports whether s only holds printable ASCII characters,
+// whose width is their number.
+func isASCIIPrintable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] >= 0x7F {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package utils
+
+import "testing"
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ASCII", "main.go", 7},
+		{"Latin-1", "café", 4},
+
+		{"CJK", "日本語", 6},
+		{"CJK and ASCII", "報告.txt", 8},
+		{"fullwidth", "ＡＢ", 4},
+		{"Hangul syllables", "한국", 4},
+		{"Hangul jamo", "\u1100\u1161\u11a8", 2},
+
+		{"combining acute", "cafe\u0301", 4},
+		{"combining marks", "a\u0300\u0301\u0302", 1},
+		{"enclosing mark", "a\u20dd", 1},
+
+		{"emoji", "🎉", 2},
+		{"skin tone", "👍🏽", 2},
+		{"flag", "🇫🇷", 1},
+		{"two flags", "🇫🇷🇩🇪", 2},
+		{"lone regional indicator", "🇫", 1},
+		{"ZWJ family", "👨‍👩‍👧", 2},
+		{"ZWJ with selector", "👁️‍🗨️", 2},
+		{"rainbow flag", "🏳️‍🌈", 2},
+
+		{"text heart", "❤", 1},
+		{"emoji heart", "❤️", 2},
+		{"emoji smile", "☺️", 2},
+		{"keycap", "1️⃣", 2},
+		{"selector after a wide character", "🎉️", 2},
+		{"selector alone", "\ufe0f", 0},
+		{"text selector", "❤\ufe0e", 1},
+
+		{"tab", "a\tb", 2},
+		{"NUL", "a\x00b", 2},
+		{"DEL", "\x7f", 0},
+		{"C1 control", "\u0085", 0},
+		{"soft hyphen", "co\u00adop", 4},
+		{"zero-width space", "a\u200bb", 2},
+		{"invalid UTF-8", "bad\xff\xfe", 5},
+	}
+	for _, tt := range tests {
+		if got := DisplayWidth(tt.s); got != tt.want {
+			t.Errorf("%s: DisplayWidth(%q) = %d, want %d", tt.name, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestPad(t *testing.T) {
+	tests := []struct {
+		s           string
+		width       int
+		right, left string
+	}{
+		{"ab", 4, "ab  ", "  ab"},
+		{"日本", 6, "日本  ", "  日本"},
+		{"❤️", 3, "❤️ ", " ❤️"},
+		{"toolong", 3, "toolong", "toolong"},
+	}
+	for _, tt := range tests {
+		if got := PadRight(tt.s, tt.width); got != tt.right {
+			t.Errorf("PadRight(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.right)
+		}
+		if got := PadLeft(tt.s, tt.width); got != tt.left {
+			t.Errorf("PadLeft(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.left)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+package utils
+
+// Exit statuses of the program, the same as the ones of GNU ls.
+const (
+	ExitOK      = 0 // success
+	ExitMinor   = 1 // minor problems, e.g. a subdirectory that cannot be opened
+	ExitSerious = 2 // serious trouble, e.g. bad arguments or an inaccessible command-line path
+)
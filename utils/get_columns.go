@@ -1,7 +1,16 @@
 package utils
 
+// GetColumns returns how many columns fit the names in width; a negative
+// width means there is no limit and every name goes on one line.
 func GetColumns(numFiles, width int, filesName []string) int {
 	columns := numFiles
+	if width < 0 {
+		return columns
+	}
+
+	// Every column takes at least one character and two spaces, so more
+	// than width/3 columns can never fit.
+	columns = min(columns, max(width/3, 1))
 
 	for IsRowBiggerThanTermWidth(columns, width, filesName) {
 		columns--
@@ -38,8 +47,8 @@ func WidthOfEachColumn(rows, columns int, allFileNames []string) map[int]int {
 		for column := range columns {
 			index := column*rows + row
 			if index < len(allFileNames) {
-				if len(allFileNames[index]) > widthOfColumns[column] {
-					widthOfColumns[column] = len(allFileNames[index])
+				if width := DisplayWidth(allFileNames[index]); width > widthOfColumns[column] {
+					widthOfColumns[column] = width
 				}
 			}
 		}
@@ -47,13 +56,3 @@ func WidthOfEachColumn(rows, columns int, allFileNames []string) map[int]int {
 
 	return widthOfColumns
 }
-
-func SortDirs(s *[]string) {
-	for i := 0; i < len(*s)-1; i++ {
-		for j := 0; j < len(*s)-i-1; j++ {
-			if (*s)[j] > (*s)[j+1] {
-				(*s)[j], (*s)[j+1] = (*s)[j+1], (*s)[j]
-			}
-		}
-	}
-}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MatchGlob reports whether name matches the shell pattern, like fnmatch(3)
+// without flags in a UTF-8 locale:
+//
+//   - `*` matches any sequence of characters, `?` any single character.
+//   - `[...]` matches one character from the set; ranges like `a-z` are
+//     allowed and a leading `!` or `^` negates the set.
+//   - `\` makes the next character match literally.
+//
+// Patterns and names are matched character by character, a byte that is not
+// part of valid UTF-8 counting as one character. A malformed pattern (such as
+// an unterminated `[`) matches its characters literally.
+func MatchGlob(pattern, name string) bool {
+	p, n := 0, 0
+	// Position to resume from after the last `*`, for backtracking.
+	starP, starN := -1, 0
+
+	for n < len(name) {
+		c, size := utf8.DecodeRuneInString(name[n:])
+		if p < len(pattern) {
+			switch pattern[p] {
+			case '*':
+				starP, starN = p, n
+				p++
+				continue
+			case '?':
+				p++
+				n += size
+				continue
+			case '[':
+				if matched, next, ok := matchClass(pattern, p, c); ok {
+					if matched {
+						p = next
+						n += size
+						continue
+					}
+				} else if c == '[' {
+					p++
+					n++
+					continue
+				}
+			case '\\':
+				if p+1 < len(pattern) {
+					if literal := charAt(pattern, p+1); literal == name[n:n+size] {
+						p += 1 + len(literal)
+						n += size
+						continue
+					}
+					break
+				}
+				fallthrough
+			default:
+				if literal := charAt(pattern, p); literal == name[n:n+size] {
+					p += len(literal)
+					n += size
+					continue
+				}
+			}
+		}
+		if starP < 0 {
+			return false
+		}
+		// Let the last `*` swallow one more character and retry.
+		_, size = utf8.DecodeRuneInString(name[starN:])
+		starN += size
+		p, n = starP+1, starN
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// charAt returns the character of s starting at s[i]: its UTF-8 sequence, or
+// the byte alone when it does not start a valid one.
+func charAt(s string, i int) string {
+	_, size := utf8.DecodeRuneInString(s[i:])
+	return s[i : i+size]
+}
+
+// MatchFileName reports whether the file name matches the shell pattern, like
+// MatchGlob, except that a leading "." of name is only matched by a "." in
+// the pattern, as with the FNM_PERIOD flag of fnmatch(3). `*` thus does not
+// match the hidden files.
+func MatchFileName(pattern, name string) bool {
+	if strings.HasPrefix(name, ".") && !strings.HasPrefix(pattern, ".") && !strings.HasPrefix(pattern, `\.`) {
+		return false
+	}
+	return MatchGlob(pattern, name)
+}
+
+// matchClass matches c against the bracket expression starting at
+// pattern[start]. It returns whether c is in the set, the index just after
+// the closing `]`, and false as last value if the expression is unterminated.
+func matchClass(pattern string, start int, c rune) (matched bool, next int, ok bool) {
+	i := start + 1
+	negate := false
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		negate = true
+		i++
+	}
+
+	// char returns the character at pattern[i], unescaped, and the index
+	// just after it.
+	char := func(i int) (rune, int) {
+		if pattern[i] == '\\' && i+1 < len(pattern) {
+			i++
+		}
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		return r, i + size
+	}
+
+	first := true
+	for i < len(pattern) {
+		if pattern[i] == ']' && !first {
+			return matched != negate, i + 1, true
+		}
+		first = false
+
+		var lo, hi rune
+		lo, i = char(i)
+		hi = lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi, i = char(i + 1)
+		}
+
+		if lo <= c && c <= hi {
+			matched = true
+		}
+	}
+	return false, 0, false
+}
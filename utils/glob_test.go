@@ -0,0 +1,105 @@
+package utils
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"", "", true},
+		{"", "a", false},
+		{"abc", "abc", true},
+		{"abc", "abd", false},
+
+		// Wildcards.
+		{"*", "", true},
+		{"*", "anything", true},
+		{"*.go", "main.go", true},
+		{"*.go", "main.go.orig", false},
+		{"a*b*c", "aXXbYYc", true},
+		{"a*b*c", "aXXbYY", false},
+		{"*a*a", "banana", true},
+		{"?", "a", true},
+		{"?", "", false},
+		{"?", "ab", false},
+		{"a?c", "abc", true},
+
+		// Bracket expressions, ranges and negation.
+		{"[abc]", "b", true},
+		{"[abc]", "d", false},
+		{"[a-c]x", "bx", true},
+		{"[a-c]x", "dx", false},
+		{"[0-9][0-9]", "42", true},
+		{"[!a-c]", "d", true},
+		{"[!a-c]", "b", false},
+		{"[^a-c]", "d", true},
+		{"[^a-c]", "a", false},
+		{"[]]", "]", true},
+		{"[!]]", "]", false},
+		{"[a-]", "-", true},
+		{"[", "[", true},
+		{"[a", "[a", true},
+		{"[a", "a", false},
+
+		// Escapes.
+		{`\*`, "*", true},
+		{`\*`, "a", false},
+		{`\?`, "?", true},
+		{`a\[b`, "a[b", true},
+		{`[\]]`, "]", true},
+		{`[a\-z]`, "-", true},
+		{`[a\-z]`, "b", false},
+		{`x\`, `x\`, true},
+
+		// UTF-8: a character is one rune, not one byte.
+		{"?", "é", true},
+		{"??", "é", false},
+		{"caf?", "café", true},
+		{"[é]", "é", true},
+		{"[!é]", "é", false},
+		{"[!é]", "e", true},
+		{"[à-ü]", "é", true},
+		{"[à-ü]", "e", false},
+		{"*é", "café", true},
+		{`\é`, "é", true},
+		{"日本*", "日本語.txt", true},
+		{"?本", "日本", true},
+
+		// Bytes that are not valid UTF-8 are characters of their own.
+		{"?", "\xff", true},
+		{"a\xffb", "a\xffb", true},
+		{"a\xffb", "a\xfeb", false},
+		{"a?b", "a\xffb", true},
+	}
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+// MatchFileName follows the FNM_PERIOD rule: a leading "." of the name is
+// only matched by a "." in the pattern.
+func TestMatchFileName(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*", ".hidden", false},
+		{"?hidden", ".hidden", false},
+		{"[.]hidden", ".hidden", false},
+		{".*", ".hidden", true},
+		{`\.*`, ".hidden", true},
+		{"*", "visible", true},
+		{"*.txt", "notes.txt", true},
+		{"a*", "a.b", true},
+		{"*~", "file~", true},
+		{"*~", ".file~", false},
+	}
+	for _, tt := range tests {
+		if got := MatchFileName(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchFileName(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// optionSpec describes one command-line option. The table below is the single
+// place where options are declared: the parser looks options up in it and the
+// --help text is generated from it.
+type optionSpec struct {
+	short  byte   // single-letter form, 0 if there is none
+	long   string // long form without the leading "--", empty if there is none
+	arg    string // name of the argument shown in --help, empty for plain flags
+	optArg bool   // the argument may be omitted (only in the --long=ARG form)
+	help   string // description shown in --help
+	apply  func(opts *Options, value string) error
+}
+
+var optionTable = []optionSpec{
+	{short: '1', help: "Lists one file per line.",
+		apply: func(opts *Options, _ string) error { opts.Layout = LayoutSingle; opts.Long = false; return nil }},
+	{short: 'a', long: "all", help: "Includes hidden files.",
+		apply: func(opts *Options, _ string) error { opts.All = true; opts.AlmostAll = false; return nil }},
+	{short: 'A', long: "almost-all", help: "Includes hidden files, except . and ..",
+		apply: func(opts *Options, _ string) error { opts.AlmostAll = true; opts.All = false; return nil }},
+	{short: 'B', long: "ignore-backups", help: "Does not list entries ending with ~.",
+		apply: func(opts *Options, _ string) error { opts.Ignore = append(opts.Ignore, "*~", ".*~"); return nil }},
+	{short: 'd', long: "directory", help: "Lists directories themselves, not their contents.",
+		apply: func(opts *Options, _ string) error { opts.Directory = true; return nil }},
+	{long: "dereference-command-line-symlink-to-dir", help: "Follows the command-line symbolic links to directories.",
+		apply: func(opts *Options, _ string) error { opts.Dereference = DerefCommandLineDirs; return nil }},
+	{short: 'F', long: "classify", help: "Appends an indicator to the names: one of */=@|.",
+		apply: func(opts *Options, _ string) error { opts.Indicator = IndicatorClassify; return nil }},
+	{long: "file-type", help: "Likewise, except that it does not append '*'.",
+		apply: func(opts *Options, _ string) error { opts.Indicator = IndicatorFileType; return nil }},
+	{long: "indicator-style", arg: "WORD", help: "Appends indicators of style WORD to the names: none, slash (-p), file-type (--file-type), classify (-F).",
+		apply: parseIndicatorStyle},
+	{short: 'f', help: "Does not sort and lists all entries; like -aU without -l and --color.",
+		apply: func(opts *Options, _ string) error {
+			opts.All = true
+			opts.AlmostAll = false
+			opts.setSort(SortNone)
+			opts.Long = false
+			opts.Color = ColorNever
+			return nil
+		}},
+	{short: 'b', long: "escape", help: "Prints C-style escapes for the unprintable characters.",
+		apply: func(opts *Options, _ string) error { opts.Quoting = QuoteEscape; return nil }},
+	{short: 'c', help: "Shows and sorts by the status change time (ctime); alone, sorts by it.",
+		apply: func(opts *Options, _ string) error { opts.setTime(TimeChange); return nil }},
+	{short: 'C', help: "Lists the entries in columns.",
+		apply: func(opts *Options, _ string) error { opts.Layout = LayoutColumns; opts.Long = false; return nil }},
+	{short: 'h', long: "human-readable", help: "With -l, prints sizes like 1K 234M 2G.",
+		apply: func(opts *Options, _ string) error { opts.BlockSize = BlockSize{Human: true, Base: 1024}; return nil }},
+	{short: 'H', long: "dereference-command-line", help: "Follows the symbolic links given on the command line.",
+		apply: func(opts *Options, _ string) error { opts.Dereference = DerefCommandLine; return nil }},
+	{long: "hide", arg: "PATTERN", help: "Does not list entries matching the shell PATTERN, unless -a or -A is given.",
+		apply: func(opts *Options, value string) error { opts.Hide = append(opts.Hide, value); return nil }},
+	{long: "git", help: "With -l, shows the git status of the files: staged and unstaged change.",
+		apply: func(opts *Options, _ string) error { opts.Git = true; return nil }},
+	{long: "gitignore", help: "Does not list entries ignored by the .gitignore files and .git/info/exclude.",
+		apply: func(opts *Options, _ string) error { opts.GitIgnore = true; return nil }},
+	{short: 'I', long: "ignore", arg: "PATTERN", help: "Does not list entries matching the shell PATTERN.",
+		apply: func(opts *Options, value string) error { opts.Ignore = append(opts.Ignore, value); return nil }},
+	{short: 'L', long: "dereference", help: "Shows the file a symbolic link points to instead of the link.",
+		apply: func(opts *Options, _ string) error { opts.Dereference = DerefAlways; return nil }},
+	{short: 'l', long: "long", help: "Enables long listing format with detailed file information.",
+		apply: func(opts *Options, _ string) error { opts.Long = true; return nil }},
+	{short: 'N', long: "literal", help: "Prints the names as they are, without quoting them.",
+		apply: func(opts *Options, _ string) error { opts.Quoting = QuoteLiteral; return nil }},
+	{short: 'n', long: "numeric-uid-gid", help: "Like -l, but lists numeric user and group IDs.",
+		apply: func(opts *Options, _ string) error { opts.Long = true; opts.NumericIDs = true; return nil }},
+	{short: 'p', help: "Appends / to the names of directories.",
+		apply: func(opts *Options, _ string) error { opts.Indicator = IndicatorSlash; return nil }},
+	{short: 'q', long: "hide-control-chars", help: "Prints ? instead of the unprintable characters.",
+		apply: func(opts *Options, _ string) error { opts.ControlChars = ControlHide; return nil }},
+	{long: "show-control-chars", help: "Prints the unprintable characters as they are.",
+		apply: func(opts *Options, _ string) error { opts.ControlChars = ControlShow; return nil }},
+	{short: 'Q', long: "quote-name", help: "Encloses the names in double quotes.",
+		apply: func(opts *Options, _ string) error { opts.Quoting = QuoteC; return nil }},
+	{long: "quoting-style", arg: "WORD", help: "Quotes the names in style WORD: literal, locale, shell, shell-always, shell-escape, shell-escape-always, c, escape.",
+		apply: parseQuotingStyleArg},
+	{short: 'r', long: "reverse", help: "Reverses the sorting order.",
+		apply: func(opts *Options, _ string) error { opts.Reverse = true; return nil }},
+	{short: 'S', help: "Sorts by file size, largest first.",
+		apply: func(opts *Options, _ string) error { opts.setSort(SortBySize); return nil }},
+	{short: 'R', long: "recursive", help: "Enables recursive listing.",
+		apply: func(opts *Options, _ string) error { opts.Recursive = true; return nil }},
+	{long: "si", help: "Like -h, but uses powers of 1000 instead of 1024.",
+		apply: func(opts *Options, _ string) error { opts.BlockSize = BlockSize{Human: true, Base: 1000}; return nil }},
+	{long: "block-size", arg: "SIZE", help: "Scales sizes by SIZE, e.g. 'M' prints sizes in units of 1,048,576 bytes.",
+		apply: parseBlockSizeArg},
+	{long: "sort", arg: "WORD", help: "Sorts by WORD instead of name: none (-U), size (-S), time (-t), version (-v), extension (-X), width.",
+		apply: parseSortWord},
+	{short: 't', help: "Sorts files by modification time.",
+		apply: func(opts *Options, _ string) error { opts.setSort(SortByTime); return nil }},
+	{long: "time", arg: "WORD", help: "Shows and sorts by WORD instead of the modification time: atime (-u), ctime (-c), birth, mtime.",
+		apply: parseTimeWord},
+	{short: 'u', help: "Shows and sorts by the access time (atime); alone, sorts by it.",
+		apply: func(opts *Options, _ string) error { opts.setTime(TimeAccess); return nil }},
+	{long: "time-style", arg: "STYLE", help: "Formats the timestamps as full-iso, long-iso, iso, locale, relative, relative-verbose or +FORMAT (strftime).",
+		apply: parseTimeStyleArg},
+	{long: "full-time", help: "Like -l --time-style=full-iso.",
+		apply: func(opts *Options, _ string) error { opts.Long = true; opts.TimeStyle = fullISOStyle; return nil }},
+	{short: 'U', help: "Does not sort; lists the entries in directory order.",
+		apply: func(opts *Options, _ string) error { opts.setSort(SortNone); return nil }},
+	{short: 'v', help: "Natural sort of the version numbers within the names.",
+		apply: func(opts *Options, _ string) error { opts.setSort(SortByVersion); return nil }},
+	{short: 'X', help: "Sorts alphabetically by extension.",
+		apply: func(opts *Options, _ string) error { opts.setSort(SortByExtension); return nil }},
+	{long: "color", arg: "WHEN", optArg: true, help: "Colors the file names; WHEN is always, auto (on a terminal) or never.",
+		apply: parseColorWhen},
+	{long: "dircolors", arg: "FILE", help: "Takes the colors from the dircolors database FILE instead of LS_COLORS.",
+		apply: func(opts *Options, value string) error { opts.ColorDB = value; return nil }},
+	{long: "format", arg: "WORD", help: "Writes the listing as WORD: text, json or ndjson.",
+		apply: parseFormatWord},
+	{long: "tree", arg: "STYLE", optArg: true, help: "Lists the subdirectories recursively as a tree; STYLE is unicode or ascii.",
+		apply: parseTreeStyle},
+	{short: 'w', long: "width", arg: "COLS", help: "Sets the output width to COLS; 0 means no limit.",
+		apply: parseWidth},
+	{long: "max-depth", arg: "N", help: "With -R, lists subdirectories at most N levels below the arguments.",
+		apply: parseMaxDepth},
+	{long: "one-file-system", help: "With -R, does not list directories on other file systems.",
+		apply: func(opts *Options, _ string) error { opts.OneFileSystem = true; return nil }},
+	{long: "jobs", arg: "N", help: "Collects file information with N concurrent workers; the default is 1.",
+		apply: parseJobs},
+	{long: "help", help: "Displays this help and exits."},
+}
+
+func parseSortWord(opts *Options, value string) error {
+	switch value {
+	case "name":
+		opts.setSort(SortByName)
+	case "time":
+		opts.setSort(SortByTime)
+	case "size":
+		opts.setSort(SortBySize)
+	case "extension":
+		opts.setSort(SortByExtension)
+	case "version":
+		opts.setSort(SortByVersion)
+	case "width":
+		opts.setSort(SortByWidth)
+	case "none":
+		opts.setSort(SortNone)
+	default:
+		return fmt.Errorf("invalid argument '%s' for '--sort'", value)
+	}
+	return nil
+}
+
+func parseTimeWord(opts *Options, value string) error {
+	switch value {
+	case "mtime", "modification":
+		opts.setTime(TimeModify)
+	case "atime", "access", "use":
+		opts.setTime(TimeAccess)
+	case "ctime", "status":
+		opts.setTime(TimeChange)
+	case "birth", "creation":
+		opts.setTime(TimeBirth)
+	default:
+		return fmt.Errorf("invalid argument '%s' for '--time'", value)
+	}
+	return nil
+}
+
+func parseTimeStyleArg(opts *Options, value string) error {
+	style, err := ParseTimeStyle(value)
+	if err != nil {
+		return err
+	}
+	opts.TimeStyle = style
+	return nil
+}
+
+func parseQuotingStyleArg(opts *Options, value string) error {
+	style, err := ParseQuotingStyle(value)
+	if err != nil {
+		return err
+	}
+	opts.Quoting = style
+	return nil
+}
+
+func parseBlockSizeArg(opts *Options, value string) error {
+	bs, err := ParseBlockSize(value)
+	if err != nil {
+		return err
+	}
+	opts.BlockSize = bs
+	return nil
+}
+
+func parseColorWhen(opts *Options, value string) error {
+	switch value {
+	case "", "always", "yes", "force":
+		opts.Color = ColorAlways
+	case "never", "no", "none":
+		opts.Color = ColorNever
+	case "auto", "tty", "if-tty":
+		opts.Color = ColorAuto
+	default:
+		return fmt.Errorf("invalid argument '%s' for '--color'", value)
+	}
+	return nil
+}
+
+func parseFormatWord(opts *Options, value string) error {
+	switch value {
+	case "text":
+		opts.Format = FormatText
+	case "json":
+		opts.Format = FormatJSON
+	case "ndjson":
+		opts.Format = FormatNDJSON
+	default:
+		return fmt.Errorf("invalid argument '%s' for '--format'", value)
+	}
+	return nil
+}
+
+func parseWidth(opts *Options, value string) error {
+	width, err := strconv.Atoi(value)
+	if err != nil || width < 0 {
+		return fmt.Errorf("invalid line width: '%s'", value)
+	}
+	if width == 0 {
+		width = -1
+	}
+	opts.Width = width
+	return nil
+}
+
+func parseJobs(opts *Options, value string) error {
+	jobs, err := strconv.Atoi(value)
+	if err != nil || jobs < 1 {
+		return fmt.Errorf("invalid number of jobs: '%s'", value)
+	}
+	opts.Jobs = jobs
+	return nil
+}
+
+func parseIndicatorStyle(opts *Options, value string) error {
+	switch value {
+	case "none":
+		opts.Indicator = IndicatorNone
+	case "slash":
+		opts.Indicator = IndicatorSlash
+	case "file-type":
+		opts.Indicator = IndicatorFileType
+	case "classify":
+		opts.Indicator = IndicatorClassify
+	default:
+		return fmt.Errorf("invalid argument '%s' for '--indicator-style'", value)
+	}
+	return nil
+}
+
+func parseTreeStyle(opts *Options, value string) error {
+	switch value {
+	case "":
+		opts.Tree = TreeAuto
+	case "unicode":
+		opts.Tree = TreeUnicode
+	case "ascii":
+		opts.Tree = TreeASCII
+	default:
+		return fmt.Errorf("invalid argument '%s' for '--tree'", value)
+	}
+	opts.Recursive = true
+	return nil
+}
+
+func parseMaxDepth(opts *Options, value string) error {
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 0 {
+		return fmt.Errorf("invalid maximum depth: '%s'", value)
+	}
+	if depth == 0 {
+		depth = -1
+	}
+	opts.MaxDepth = depth
+	return nil
+}
+
+// helpText builds the --help output from the option table. The descriptions
+// are aligned after the longest names.
+func helpText() string {
+	var b strings.Builder
+
+	b.WriteString("Usage: ./myls [OPTION]... [FILE]...\n")
+	b.WriteString("List information about the FILEs (the current directory by default).\n")
+	b.WriteString("Options:\n")
+
+	names := make([]string, len(optionTable))
+	width := 0
+	for i, spec := range optionTable {
+		names[i] = optionNames(spec)
+		width = max(width, len(names[i]))
+	}
+	for i, spec := range optionTable {
+		fmt.Fprintf(&b, "  %-*s  %s\n", width, names[i], spec.help)
+	}
+	return b.String()
+}
+
+// optionNames returns the names of an option as listed by --help, e.g.
+// "-w, --width=COLS".
+func optionNames(spec optionSpec) string {
+	names := "    "
+	if spec.short != 0 {
+		names = "-" + string(spec.short)
+		if spec.long != "" {
+			names += ", "
+		} else if spec.arg != "" {
+			names += " " + spec.arg
+		}
+	}
+	if spec.long != "" {
+		names += "--" + spec.long
+		switch {
+		case spec.arg != "" && spec.optArg:
+			names += "[=" + spec.arg + "]"
+		case spec.arg != "":
+			names += "=" + spec.arg
+		}
+	}
+	return names
+}
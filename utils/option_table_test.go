@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// Every description in --help starts in the same column, at least two spaces
+// after the names of its option, however long they are.
+func TestHelpTextAlignment(t *testing.T) {
+	lines := strings.Split(helpText(), "\n")
+	column := -1
+	for _, spec := range optionTable {
+		names := optionNames(spec)
+		found := false
+		for _, line := range lines {
+			if !strings.HasPrefix(line, "  "+names+"  ") || !strings.HasSuffix(line, spec.help) {
+				continue
+			}
+			found = true
+			if col := len(line) - len(spec.help); column < 0 {
+				column = col
+			} else if col != column {
+				t.Errorf("the description of %q starts in column %d, want %d", names, col, column)
+			}
+		}
+		if !found {
+			t.Errorf("helpText() has no line for %q", names)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package utils
+
+import (
+	"os"
+	"strings"
+)
+
+// SortMode selects the key used to order the listed entries.
+type SortMode int
+
+const (
+	SortByName      SortMode = iota // default, alphabetical order
+	SortByTime                      // -t : modification time, newest first
+	SortBySize                      // -S : file size, largest first
+	SortByExtension                 // -X : alphabetical order of the extensions
+	SortByVersion                   // -v : natural order of the version numbers in the names
+	SortByWidth                     // --sort=width : length of the names, shortest first
+	SortNone                        // -U, -f : directory order, no sorting at all
+)
+
+// TimeField selects the timestamp shown by -l and used by -t.
+type TimeField int
+
+const (
+	TimeModify TimeField = iota // default, last modification of the content
+	TimeAccess                  // -u, --time=atime : last access
+	TimeChange                  // -c, --time=ctime : last change of the status
+	TimeBirth                   // --time=birth : creation, when the file system records it
+)
+
+// Dereference selects which symbolic links are followed, showing the file
+// they point to instead of the link itself.
+type Dereference int
+
+const (
+	DerefDefault         Dereference = iota // DerefNever with -l, -d or -F, else DerefCommandLineDirs
+	DerefNever                              // show every link itself
+	DerefCommandLineDirs                    // --dereference-command-line-symlink-to-dir : follow command-line links to directories
+	DerefCommandLine                        // -H : follow the links given on the command line
+	DerefAlways                             // -L : follow every link
+)
+
+// ColorMode selects when file names are colored.
+type ColorMode int
+
+const (
+	ColorAuto   ColorMode = iota // default, color the output only on a terminal
+	ColorAlways                  // --color=always
+	ColorNever                   // --color=never
+)
+
+// Layout selects how the short format arranges the names.
+type Layout int
+
+const (
+	LayoutAuto    Layout = iota // default, columns on a terminal and one name per line otherwise
+	LayoutColumns               // -C : names in columns
+	LayoutSingle                // -1 : one name per line
+)
+
+// IndicatorStyle selects the marks appended to the names to show their type.
+type IndicatorStyle int
+
+const (
+	IndicatorNone     IndicatorStyle = iota // default, no marks
+	IndicatorSlash                          // -p : "/" after directories
+	IndicatorFileType                       // --file-type : also "@" for links, "|" for FIFOs and "=" for sockets
+	IndicatorClassify                       // -F : also "*" for executables
+)
+
+// TreeStyle selects whether and how --tree draws the directory hierarchy.
+type TreeStyle int
+
+const (
+	TreeNone    TreeStyle = iota // default, no tree
+	TreeAuto                     // --tree : box-drawing lines in a UTF-8 locale, ASCII otherwise
+	TreeUnicode                  // --tree=unicode : box-drawing lines
+	TreeASCII                    // --tree=ascii : ASCII lines
+)
+
+// OutputFormat selects how the listing is written.
+type OutputFormat int
+
+const (
+	FormatText   OutputFormat = iota // default, the text output of ls
+	FormatJSON                       // --format=json : one JSON document
+	FormatNDJSON                     // --format=ndjson : one JSON object per line and entry
+)
+
+// Options holds every setting that controls how paths are listed and printed.
+// It is filled from the command line by Args, but can also be built directly
+// by programs that use the listing API of the logic package.
+type Options struct {
+	Long          bool           // -l : long listing format with detailed file information
+	Recursive     bool           // -R : list subdirectories recursively
+	Directory     bool           // -d : list directories themselves, not their contents
+	Dereference   Dereference    // -L, -H : symbolic links to follow
+	All           bool           // -a : include entries whose names start with "."
+	AlmostAll     bool           // -A : like -a, without "." and ".."
+	Reverse       bool           // -r : reverse the sort order
+	Sort          SortMode       // -t, -S, -X, -v, -U, --sort : key used to order the entries
+	Time          TimeField      // -u, -c, --time : timestamp shown and sorted by
+	TimeStyle     TimeStyle      // --time-style, --full-time : format of the timestamps
+	Color         ColorMode      // --color : when to color file names
+	Format        OutputFormat   // --format : text, json or ndjson output
+	Layout        Layout         // -1, -C : arrangement of the short format
+	Indicator     IndicatorStyle // -F, -p, --file-type, --indicator-style : marks after the names
+	Tree          TreeStyle      // --tree : draw the directory hierarchy as a tree
+	Quoting       QuotingStyle   // -Q, -b, -N, --quoting-style : how the names are quoted
+	ControlChars  ControlChars   // -q, --show-control-chars : whether unprintable characters show as "?"
+	ColorDB       string         // --dircolors : dircolors database to take the colors from
+	Width         int            // -w : output width; 0 detects it, negative means unlimited
+	Ignore        []string       // -I, -B : shell patterns of entries to leave out
+	Hide          []string       // --hide : like Ignore, unless -a or -A is given
+	GitIgnore     bool           // --gitignore : leave out the entries git ignores
+	Git           bool           // --git : with -l, show the git status of the files
+	BlockSize     BlockSize      // -h, --si, --block-size : unit of the sizes
+	NumericIDs    bool           // -n : print user and group ids instead of names
+	Jobs          int            // --jobs : concurrent metadata lookups; 0 and 1 are serial
+	MaxDepth      int            // --max-depth : levels of subdirectories -R lists; 0 means no limit, negative none
+	OneFileSystem bool           // --one-file-system : -R does not enter directories on other file systems
+
+	sortChosen bool // a sort option was given
+	timeChosen bool // -u, -c or --time was given
+}
+
+// setSort selects the sort mode of a sort option.
+func (opts *Options) setSort(mode SortMode) {
+	opts.Sort = mode
+	opts.sortChosen = true
+}
+
+// setTime selects the timestamp of a time option.
+func (opts *Options) setTime(field TimeField) {
+	opts.Time = field
+	opts.timeChosen = true
+}
+
+// resolveTimeSort applies the rule of GNU ls that choosing a timestamp
+// without -l and without a sort option sorts by that timestamp.
+func (opts *Options) resolveTimeSort() {
+	if opts.timeChosen && !opts.sortChosen && !opts.Long {
+		opts.Sort = SortByTime
+	}
+}
+
+// SymlinkPolicy returns the symbolic links to follow, resolving
+// DerefDefault the way GNU ls does.
+func (opts Options) SymlinkPolicy() Dereference {
+	if opts.Dereference != DerefDefault {
+		return opts.Dereference
+	}
+	if opts.Long || opts.Directory || opts.Indicator == IndicatorClassify {
+		return DerefNever
+	}
+	return DerefCommandLineDirs
+}
+
+// UseColor reports whether file names should be printed with colors.
+func (opts Options) UseColor() bool {
+	return opts.Color != ColorNever
+}
+
+// ResolveOutput replaces the automatic output settings of opts by what they
+// amount to for the current stdout, so they are worked out once per run:
+//
+//   - Width becomes the width of the terminal (see OutputWidth).
+//   - Layout becomes columns on a terminal and one name per line otherwise.
+//   - Color, when auto, is on for a terminal. NO_COLOR turns it off and
+//     CLICOLOR_FORCE turns it on whatever stdout is.
+//   - BlockSize, when not chosen, comes from LS_BLOCK_SIZE or BLOCK_SIZE.
+//   - TimeStyle, when not chosen, comes from TIME_STYLE.
+//   - Tree, when auto, draws box-drawing lines in a UTF-8 locale.
+//   - Quoting, when not chosen, comes from QUOTING_STYLE, else is
+//     shell-escape on a terminal and literal otherwise.
+//   - ControlChars, when auto, hides the unprintable characters on a terminal.
+func ResolveOutput(opts Options) Options {
+	terminal := IsTerminal(os.Stdout.Fd())
+
+	if opts.Width == 0 {
+		opts.Width = OutputWidth()
+	}
+
+	if opts.Layout == LayoutAuto {
+		opts.Layout = LayoutSingle
+		if terminal {
+			opts.Layout = LayoutColumns
+		}
+	}
+
+	if opts.Color == ColorAuto {
+		switch force := os.Getenv("CLICOLOR_FORCE"); {
+		case force != "" && force != "0":
+			opts.Color = ColorAlways
+		case os.Getenv("NO_COLOR") != "":
+			opts.Color = ColorNever
+		case terminal:
+			opts.Color = ColorAlways
+		default:
+			opts.Color = ColorNever
+		}
+	}
+
+	if opts.BlockSize.IsDefault() {
+		if bs, ok := blockSizeFromEnv(); ok {
+			opts.BlockSize = bs
+		}
+	}
+
+	if opts.TimeStyle.IsDefault() {
+		if style, ok := timeStyleFromEnv(); ok {
+			opts.TimeStyle = style
+		}
+	}
+
+	if opts.Tree == TreeAuto {
+		opts.Tree = TreeASCII
+		if isUTF8Locale() {
+			opts.Tree = TreeUnicode
+		}
+	}
+
+	if opts.Quoting == QuoteDefault {
+		if style, ok := quotingStyleFromEnv(); ok {
+			opts.Quoting = style
+		} else if terminal {
+			opts.Quoting = QuoteShellEscape
+		} else {
+			opts.Quoting = QuoteLiteral
+		}
+	}
+
+	if opts.ControlChars == ControlAuto {
+		opts.ControlChars = ControlShow
+		if terminal {
+			opts.ControlChars = ControlHide
+		}
+	}
+
+	return opts
+}
+
+// isUTF8Locale reports whether the character set of the locale is UTF-8.
+func isUTF8Locale() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			locale = strings.ToLower(locale)
+			return strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8")
+		}
+	}
+	return false
+}
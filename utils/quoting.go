@@ -0,0 +1,285 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// QuotingStyle selects how file names are quoted and how the characters a
+// terminal cannot show are written, as in GNU ls.
+type QuotingStyle int
+
+const (
+	QuoteDefault           QuotingStyle = iota // QUOTING_STYLE, else shell-escape on a terminal and literal otherwise
+	QuoteLiteral                               // -N : names as they are
+	QuoteLocale                                // quotes of the locale, with C escapes
+	QuoteShell                                 // quotes for the shell when needed
+	QuoteShellAlways                           // quotes for the shell always
+	QuoteShellEscape                           // like shell, with $'\n' escapes for the unprintable characters
+	QuoteShellEscapeAlways                     // like shell-always, with $'\n' escapes
+	QuoteC                                     // -Q : double quotes and C escapes
+	QuoteEscape                                // -b : C escapes without quotes
+)
+
+// ControlChars selects whether unprintable characters are shown as "?".
+type ControlChars int
+
+const (
+	ControlAuto ControlChars = iota // hidden on a terminal, shown otherwise
+	ControlHide                     // -q : print them as "?"
+	ControlShow                     // --show-control-chars : print them as they are
+)
+
+// quotingStyles are the names --quoting-style and QUOTING_STYLE accept.
+var quotingStyles = map[string]QuotingStyle{
+	"literal":             QuoteLiteral,
+	"locale":              QuoteLocale,
+	"shell":               QuoteShell,
+	"shell-always":        QuoteShellAlways,
+	"shell-escape":        QuoteShellEscape,
+	"shell-escape-always": QuoteShellEscapeAlways,
+	"c":                   QuoteC,
+	"escape":              QuoteEscape,
+}
+
+// ParseQuotingStyle returns the quoting style named name.
+func ParseQuotingStyle(name string) (QuotingStyle, error) {
+	if style, ok := quotingStyles[name]; ok {
+		return style, nil
+	}
+	return QuoteDefault, fmt.Errorf("invalid argument '%s' for '--quoting-style'", name)
+}
+
+// quotingStyleFromEnv returns the style set by the QUOTING_STYLE environment
+// variable. Invalid values are ignored.
+func quotingStyleFromEnv() (QuotingStyle, bool) {
+	style, err := ParseQuotingStyle(os.Getenv("QUOTING_STYLE"))
+	return style, err == nil
+}
+
+// shellSpecial are the characters that make the shell styles quote a name
+// wherever they are; `#` and `~` only matter at its start.
+const shellSpecial = " \t\n!\"$&'()*;<=>?[\\^`|"
+
+// QuoteName writes name in the quoting style. With hide set, the
+// unprintable characters of the styles that do not escape them are printed
+// as "?". Characters are printable when the locale can show them: in a UTF-8
+// locale the printable Unicode characters, otherwise printable ASCII only.
+func QuoteName(name string, style QuotingStyle, hide bool) string {
+	utf8Locale := isUTF8Locale()
+
+	switch style {
+	case QuoteC:
+		return `"` + cEscape(name, utf8Locale, `"`) + `"`
+	case QuoteEscape:
+		return strings.ReplaceAll(cEscape(name, utf8Locale, ""), " ", `\ `)
+	case QuoteLocale:
+		if utf8Locale {
+			return "‘" + cEscape(name, utf8Locale, "") + "’"
+		}
+		return "'" + cEscape(name, utf8Locale, "'") + "'"
+	case QuoteShellEscape, QuoteShellEscapeAlways:
+		if !isPrintableName(name, utf8Locale) {
+			return shellEscape(name, utf8Locale)
+		}
+	}
+
+	// The shell styles decide on the quotes from the name itself: the "?"
+	// that hides a character does not need them.
+	quote := style == QuoteShellAlways || style == QuoteShellEscapeAlways ||
+		(style == QuoteShell || style == QuoteShellEscape) && needsShellQuotes(name)
+	double := quote && doubleQuotable(name, utf8Locale)
+	if hide {
+		name = hideUnprintable(name, utf8Locale)
+	}
+	if quote {
+		return shellQuote(name, double)
+	}
+	return name
+}
+
+// needsShellQuotes reports whether the shell would read name as something
+// else than the name itself.
+func needsShellQuotes(name string) bool {
+	if name == "" || name == "{" || name == "}" {
+		return true
+	}
+	if name[0] == '#' || name[0] == '~' {
+		return true
+	}
+	return strings.ContainsAny(name, shellSpecial)
+}
+
+// doubleQuoteSafe are the ASCII characters GNU ls leaves between double
+// quotes; `#` and `~` are also left at the start of a name.
+const doubleQuoteSafe = " %'+,-.0123456789:@ABCDEFGHIJKLMNOPQRSTUVWXYZ]_abcdefghijklmnopqrstuvwxyz"
+
+// doubleQuotable reports whether name holds a single quote and otherwise
+// only characters that read the same between double quotes as in C, which
+// is when GNU ls writes it between double quotes rather than single ones.
+func doubleQuotable(name string, utf8Locale bool) bool {
+	if !strings.Contains(name, "'") {
+		return false
+	}
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		switch {
+		case r >= utf8.RuneSelf:
+			if !isPrintableRune(r, size, utf8Locale) {
+				return false
+			}
+		case i == 0 && (r == '#' || r == '~'):
+		case !strings.ContainsRune(doubleQuoteSafe, r):
+			return false
+		}
+		i += size
+	}
+	return true
+}
+
+// shellQuote quotes name for the shell, with double quotes when double is
+// set and with single quotes otherwise.
+func shellQuote(name string, double bool) string {
+	if double {
+		return `"` + name + `"`
+	}
+	return "'" + strings.ReplaceAll(name, "'", `'\''`) + "'"
+}
+
+// shellEscape single-quotes name for the shell, writing every run of
+// unprintable characters as a $'...' string between the quoted parts:
+//
+//	'nl'$'\n''x'
+func shellEscape(name string, utf8Locale bool) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	escaping := false
+
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		printable := isPrintableRune(r, size, utf8Locale)
+
+		switch {
+		case !printable && !escaping:
+			b.WriteString(`'$'`)
+			escaping = true
+		case printable && escaping:
+			// A single quote closes the $'...' string itself.
+			if r != '\'' {
+				b.WriteString(`''`)
+			}
+			escaping = false
+		}
+
+		if printable {
+			if r == '\'' {
+				b.WriteString(`'\''`)
+			} else {
+				b.WriteString(name[i : i+size])
+			}
+		} else {
+			for j := i; j < i+size; j++ {
+				b.WriteString(escapeByte(name[j]))
+			}
+		}
+		i += size
+	}
+
+	// The closing quote ends the $'...' string when the name ends with one.
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// cEscape writes name with the backslash escapes of C for the unprintable
+// characters, the backslash and the characters of quote.
+func cEscape(name string, utf8Locale bool, quote string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case size == 1 && strings.IndexByte(quote, name[i]) >= 0:
+			b.WriteByte('\\')
+			b.WriteByte(name[i])
+		case isPrintableRune(r, size, utf8Locale):
+			b.WriteString(name[i : i+size])
+		default:
+			for j := i; j < i+size; j++ {
+				b.WriteString(escapeByte(name[j]))
+			}
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// escapeByte returns the C escape of an unprintable byte: a letter escape
+// such as `\n` where there is one, else three octal digits.
+func escapeByte(c byte) string {
+	switch c {
+	case '\a':
+		return `\a`
+	case '\b':
+		return `\b`
+	case '\f':
+		return `\f`
+	case '\n':
+		return `\n`
+	case '\r':
+		return `\r`
+	case '\t':
+		return `\t`
+	case '\v':
+		return `\v`
+	}
+	return fmt.Sprintf(`\%03o`, c)
+}
+
+// hideUnprintable replaces every unprintable byte of name by "?".
+func hideUnprintable(name string, utf8Locale bool) string {
+	if isPrintableName(name, utf8Locale) {
+		return name
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		if isPrintableRune(r, size, utf8Locale) {
+			b.WriteString(name[i : i+size])
+		} else {
+			b.WriteString(strings.Repeat("?", size))
+		}
+		i += size
+	}
+	return b.String()
+}
+
+func isPrintableName(name string, utf8Locale bool) bool {
+	if isASCIIPrintable(name) {
+		return true
+	}
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		if !isPrintableRune(r, size, utf8Locale) {
+			return false
+		}
+		i += size
+	}
+	return true
+}
+
+// isPrintableRune reports whether the character r, encoded in size bytes,
+// can be shown as it is. An invalid byte decodes as utf8.RuneError of size 1.
+func isPrintableRune(r rune, size int, utf8Locale bool) bool {
+	if r < utf8.RuneSelf {
+		return r >= 0x20 && r < 0x7F
+	}
+	if !utf8Locale || (r == utf8.RuneError && size == 1) {
+		return false
+	}
+	return unicode.IsPrint(r) || unicode.Is(unicode.Mn, r) || r == zeroWidthJoiner
+}
@@ -0,0 +1,130 @@
+package utils
+
+import "testing"
+
+// The expected names are those GNU ls prints with --quoting-style, with -q
+// for hide and --show-control-chars otherwise, under LC_ALL=C.UTF-8 and
+// LC_ALL=C.
+func TestQuoteName(t *testing.T) {
+	tests := []struct {
+		style   QuotingStyle
+		hide    bool
+		name    string
+		utf8, c string
+	}{
+		{QuoteLiteral, true, "plain", "plain", "plain"},
+		{QuoteLiteral, true, "with space", "with space", "with space"},
+		{QuoteLiteral, true, `say "hi"`, `say "hi"`, `say "hi"`},
+		{QuoteLiteral, true, "tab\there", "tab?here", "tab?here"},
+		{QuoteLiteral, false, "tab\there", "tab\there", "tab\there"},
+		{QuoteLiteral, true, "bell\a", "bell?", "bell?"},
+		{QuoteLiteral, true, "bad\xff", "bad?", "bad?"},
+		{QuoteLiteral, false, "bad\xff", "bad\xff", "bad\xff"},
+		{QuoteLiteral, true, "é", "é", "??"},
+		{QuoteLiteral, false, "é", "é", "é"},
+
+		{QuoteShell, true, "plain", "plain", "plain"},
+		{QuoteShell, true, "with space", "'with space'", "'with space'"},
+		{QuoteShell, true, "it's", `"it's"`, `"it's"`},
+		{QuoteShell, true, `say "hi"`, `'say "hi"'`, `'say "hi"'`},
+		{QuoteShell, true, `both'"`, `'both'\''"'`, `'both'\''"'`},
+		{QuoteShell, true, "back\\slash", `'back\slash'`, `'back\slash'`},
+		{QuoteShell, true, "$HOME", "'$HOME'", "'$HOME'"},
+		{QuoteShell, true, "#hash", "'#hash'", "'#hash'"},
+		{QuoteShell, true, "a#b", "a#b", "a#b"},
+		{QuoteShell, true, "~tilde", "'~tilde'", "'~tilde'"},
+		{QuoteShell, true, "new\nline", "'new?line'", "'new?line'"},
+		{QuoteShell, false, "new\nline", "'new\nline'", "'new\nline'"},
+		{QuoteShell, true, "bell\a", "bell?", "bell?"},
+		{QuoteShell, true, "bad\xff", "bad?", "bad?"},
+		{QuoteShell, true, "é\n", "'é?'", "'???'"},
+		{QuoteShell, true, "it's*", `'it'\''s*'`, `'it'\''s*'`},
+		{QuoteShell, true, "it's~", `'it'\''s~'`, `'it'\''s~'`},
+		{QuoteShell, true, "~it's", `"~it's"`, `"~it's"`},
+		{QuoteShell, true, "it's é", `"it's é"`, `'it'\''s ??'`},
+		{QuoteShell, false, "it's é", `"it's é"`, `'it'\''s é'`},
+		{QuoteShell, true, "it's\a", `'it'\''s?'`, `'it'\''s?'`},
+
+		{QuoteShellAlways, true, "plain", "'plain'", "'plain'"},
+		{QuoteShellAlways, true, "it's", `"it's"`, `"it's"`},
+		{QuoteShellAlways, true, `both'"`, `'both'\''"'`, `'both'\''"'`},
+		{QuoteShellAlways, true, "bell\a", "'bell?'", "'bell?'"},
+		{QuoteShellAlways, false, "bell\a", "'bell\a'", "'bell\a'"},
+		{QuoteShellAlways, true, "é", "'é'", "'??'"},
+		{QuoteShellAlways, false, "bad\xff", "'bad\xff'", "'bad\xff'"},
+
+		{QuoteShellEscape, true, "plain", "plain", "plain"},
+		{QuoteShellEscape, true, "with space", "'with space'", "'with space'"},
+		{QuoteShellEscape, true, "it's", `"it's"`, `"it's"`},
+		{QuoteShellEscape, true, "tab\there", `'tab'$'\t''here'`, `'tab'$'\t''here'`},
+		{QuoteShellEscape, false, "new\nline", `'new'$'\n''line'`, `'new'$'\n''line'`},
+		{QuoteShellEscape, true, "bell\a", `'bell'$'\a'`, `'bell'$'\a'`},
+		{QuoteShellEscape, true, "bad\xff", `'bad'$'\377'`, `'bad'$'\377'`},
+		{QuoteShellEscape, true, "é", "é", `''$'\303\251'`},
+		{QuoteShellEscape, true, "é\n", `'é'$'\n'`, `''$'\303\251\n'`},
+		{QuoteShellEscape, true, "a\t'b", `'a'$'\t'\''b'`, `'a'$'\t'\''b'`},
+		{QuoteShellEscape, true, "\t'", `''$'\t'\'''`, `''$'\t'\'''`},
+		{QuoteShellEscape, true, "'a\t", `''\''a'$'\t'`, `''\''a'$'\t'`},
+
+		{QuoteShellEscapeAlways, true, "plain", "'plain'", "'plain'"},
+		{QuoteShellEscapeAlways, true, "a#b", "'a#b'", "'a#b'"},
+		{QuoteShellEscapeAlways, true, "new\nline", `'new'$'\n''line'`, `'new'$'\n''line'`},
+		{QuoteShellEscapeAlways, true, "é", "'é'", `''$'\303\251'`},
+
+		{QuoteC, true, "plain", `"plain"`, `"plain"`},
+		{QuoteC, true, "with space", `"with space"`, `"with space"`},
+		{QuoteC, true, "it's", `"it's"`, `"it's"`},
+		{QuoteC, true, `say "hi"`, `"say \"hi\""`, `"say \"hi\""`},
+		{QuoteC, true, "back\\slash", `"back\\slash"`, `"back\\slash"`},
+		{QuoteC, true, "tab\there", `"tab\there"`, `"tab\there"`},
+		{QuoteC, false, "new\nline", `"new\nline"`, `"new\nline"`},
+		{QuoteC, true, "bell\a", `"bell\a"`, `"bell\a"`},
+		{QuoteC, true, "bad\xff", `"bad\377"`, `"bad\377"`},
+		{QuoteC, true, "é", `"é"`, `"\303\251"`},
+
+		{QuoteEscape, true, "plain", "plain", "plain"},
+		{QuoteEscape, true, "with space", `with\ space`, `with\ space`},
+		{QuoteEscape, true, `say "hi"`, `say\ "hi"`, `say\ "hi"`},
+		{QuoteEscape, true, "it's", "it's", "it's"},
+		{QuoteEscape, true, "back\\slash", `back\\slash`, `back\\slash`},
+		{QuoteEscape, false, "new\nline", `new\nline`, `new\nline`},
+		{QuoteEscape, true, "bad\xff", `bad\377`, `bad\377`},
+		{QuoteEscape, true, "é\n", `é\n`, `\303\251\n`},
+
+		{QuoteLocale, true, "plain", "‘plain’", "'plain'"},
+		{QuoteLocale, true, "with space", "‘with space’", "'with space'"},
+		{QuoteLocale, true, "it's", "‘it's’", `'it\'s'`},
+		{QuoteLocale, true, `both'"`, `‘both'"’`, `'both\'"'`},
+		{QuoteLocale, true, "back\\slash", `‘back\\slash’`, `'back\\slash'`},
+		{QuoteLocale, false, "tab\there", `‘tab\there’`, `'tab\there'`},
+		{QuoteLocale, true, "bad\xff", `‘bad\377’`, `'bad\377'`},
+		{QuoteLocale, true, "é", "‘é’", `'\303\251'`},
+	}
+	for _, locale := range []string{"C.UTF-8", "C"} {
+		t.Run(locale, func(t *testing.T) {
+			t.Setenv("LC_ALL", locale)
+			for _, tt := range tests {
+				want := tt.utf8
+				if locale == "C" {
+					want = tt.c
+				}
+				if got := QuoteName(tt.name, tt.style, tt.hide); got != want {
+					t.Errorf("QuoteName(%q, %d, %v) = %q, want %q", tt.name, tt.style, tt.hide, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseQuotingStyle(t *testing.T) {
+	for name, want := range quotingStyles {
+		if got, err := ParseQuotingStyle(name); err != nil || got != want {
+			t.Errorf("ParseQuotingStyle(%q) = %d, %v, want %d", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"", "Shell", "clocale", "shell-"} {
+		if _, err := ParseQuotingStyle(name); err == nil {
+			t.Errorf("ParseQuotingStyle(%q) succeeded, want an error", name)
+		}
+	}
+}
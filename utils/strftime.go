@@ -0,0 +1,270 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Strftime formats t like strftime(3) of the C library with the GNU
+// extensions ls uses in --time-style formats: `%N` (nanoseconds, its width
+// giving the number of digits), `%q` (quarter), `%:z` to `%:::z`, and the
+// flags `-` (no padding), `_` (pad with spaces), `0` (pad with zeros), `^`
+// (upper case) and `#` (swap the case of names) with an optional field
+// width, as in `%-d` or `%_5Y`. Unknown conversions are copied as is,
+// padded to the width.
+func Strftime(format string, t time.Time) string {
+	var b strings.Builder
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+
+		start := i
+		i++
+
+		var pad byte
+		upper, swap := false, false
+		for ; i < len(format) && strings.IndexByte("-_0^#", format[i]) >= 0; i++ {
+			switch format[i] {
+			case '^':
+				upper = true
+			case '#':
+				swap = true
+			default:
+				pad = format[i]
+			}
+		}
+
+		width := -1
+		for ; i < len(format) && format[i] >= '0' && format[i] <= '9'; i++ {
+			width = max(width, 0)*10 + int(format[i]-'0')
+		}
+
+		colons := 0
+		for ; i < len(format) && format[i] == ':'; i++ {
+			colons++
+		}
+		if i == len(format) {
+			b.WriteString(padField(format[start:], pad, ' ', width, 0))
+			break
+		}
+
+		conv := format[i]
+		if colons > 0 && (conv != 'z' || colons > 3) {
+			// Only %z takes colons; the text after them is read again.
+			b.WriteString(padField(format[start:i], pad, ' ', width, 0))
+			i--
+			continue
+		}
+		if conv == 'N' {
+			b.WriteString(nanoseconds(t, width, pad))
+			continue
+		}
+
+		text, defaultPad, defaultWidth, ok := convert(conv, colons, t)
+		if !ok {
+			b.WriteString(padField(format[start:i+1], pad, ' ', width, 0))
+			continue
+		}
+		// As in glibc, `#` lowers the case of %p and %Z, which are in upper
+		// case, and raises that of the names; %P stays in lower case.
+		switch {
+		case conv == 'P' || swap && (conv == 'p' || conv == 'Z'):
+			text = strings.ToLower(text)
+		case upper || swap && strings.IndexByte("aAbBh", conv) >= 0:
+			text = strings.ToUpper(text)
+		}
+		b.WriteString(padField(text, pad, defaultPad, width, defaultWidth))
+	}
+
+	return b.String()
+}
+
+// convert returns the text of the conversion conv for t, and the padding
+// character and width it is printed with when no flag or width is given.
+// Numbers are returned without padding so the flags can pad them.
+func convert(conv byte, colons int, t time.Time) (text string, pad byte, width int, ok bool) {
+	num := func(n, width int) (string, byte, int, bool) {
+		return strconv.Itoa(n), '0', width, true
+	}
+	spaced := func(n, width int) (string, byte, int, bool) {
+		return strconv.Itoa(n), '_', width, true
+	}
+	str := func(s string) (string, byte, int, bool) {
+		return s, ' ', 0, true
+	}
+
+	switch conv {
+	case 'a':
+		return str(t.Weekday().String()[:3])
+	case 'A':
+		return str(t.Weekday().String())
+	case 'b', 'h':
+		return str(t.Month().String()[:3])
+	case 'B':
+		return str(t.Month().String())
+	case 'c':
+		return str(Strftime("%a %b %e %H:%M:%S %Y", t))
+	case 'C':
+		return num(t.Year()/100, 2)
+	case 'd':
+		return num(t.Day(), 2)
+	case 'D', 'x':
+		return str(Strftime("%m/%d/%y", t))
+	case 'e':
+		return spaced(t.Day(), 2)
+	case 'F':
+		return str(Strftime("%Y-%m-%d", t))
+	case 'G':
+		year, _ := t.ISOWeek()
+		return num(year, 4)
+	case 'g':
+		year, _ := t.ISOWeek()
+		return num(year%100, 2)
+	case 'H':
+		return num(t.Hour(), 2)
+	case 'I':
+		return num(hour12(t), 2)
+	case 'j':
+		return num(t.YearDay(), 3)
+	case 'k':
+		return spaced(t.Hour(), 2)
+	case 'l':
+		return spaced(hour12(t), 2)
+	case 'm':
+		return num(int(t.Month()), 2)
+	case 'M':
+		return num(t.Minute(), 2)
+	case 'n':
+		return str("\n")
+	case 'p':
+		if t.Hour() < 12 {
+			return str("AM")
+		}
+		return str("PM")
+	case 'P':
+		if t.Hour() < 12 {
+			return str("am")
+		}
+		return str("pm")
+	case 'q':
+		return num((int(t.Month())+2)/3, 1)
+	case 'r':
+		return str(Strftime("%I:%M:%S %p", t))
+	case 'R':
+		return str(Strftime("%H:%M", t))
+	case 's':
+		return num(int(t.Unix()), 1)
+	case 'S':
+		return num(t.Second(), 2)
+	case 't':
+		return str("\t")
+	case 'T', 'X':
+		return str(Strftime("%H:%M:%S", t))
+	case 'u':
+		return num((int(t.Weekday())+6)%7+1, 1)
+	case 'U':
+		return num((t.YearDay()+6-int(t.Weekday()))/7, 2)
+	case 'V':
+		_, week := t.ISOWeek()
+		return num(week, 2)
+	case 'w':
+		return num(int(t.Weekday()), 1)
+	case 'W':
+		return num((t.YearDay()+6-(int(t.Weekday())+6)%7)/7, 2)
+	case 'y':
+		return num(t.Year()%100, 2)
+	case 'Y':
+		return num(t.Year(), 1)
+	case 'z':
+		return str(zoneOffset(t, colons))
+	case 'Z':
+		name, _ := t.Zone()
+		return str(name)
+	case '%':
+		return str("%")
+	}
+	return "", 0, 0, false
+}
+
+// padField pads text to width, or to defaultWidth when no width is given,
+// with the character of the flag pad or else defaultPad. The `-` flag turns
+// the padding off.
+func padField(text string, pad, defaultPad byte, width, defaultWidth int) string {
+	if pad == 0 {
+		pad = defaultPad
+	}
+	if width < 0 {
+		width = defaultWidth
+	}
+	if pad == '-' || len(text) >= width {
+		return text
+	}
+
+	fill := " "
+	if pad == '0' {
+		fill = "0"
+	}
+	return strings.Repeat(fill, width-len(text)) + text
+}
+
+// nanoseconds formats the fraction of a second of t with 9 digits, or as
+// many as the width, padded on the right with zeros. As in GNU date, the `_`
+// flag turns the trailing zeros into spaces and, with a width, the `-` flag
+// drops them.
+func nanoseconds(t time.Time, width int, pad byte) string {
+	ns := strconv.Itoa(t.Nanosecond())
+	ns = strings.Repeat("0", 9-len(ns)) + ns
+	if width < 0 {
+		if pad != '_' {
+			return ns
+		}
+		width = 9
+	}
+
+	digits := ns[:min(width, 9)]
+	if pad == '-' || pad == '_' {
+		if digits = strings.TrimRight(digits, "0"); digits == "" {
+			digits = "0"
+		}
+	}
+	switch pad {
+	case '-':
+		return digits
+	case '_':
+		return digits + strings.Repeat(" ", width-len(digits))
+	}
+	return digits + strings.Repeat("0", width-len(digits))
+}
+
+// zoneOffset formats the offset of the time zone of t from UTC: "+0530",
+// "+05:30" with one colon, "+05:30:00" with two, and with three only as
+// precisely as needed, "+05:30" or "+05".
+func zoneOffset(t time.Time, colons int) string {
+	switch colons {
+	case 0:
+		return t.Format("-0700")
+	case 1:
+		return t.Format("-07:00")
+	case 2:
+		return t.Format("-07:00:00")
+	}
+	_, offset := t.Zone()
+	switch {
+	case offset%60 != 0:
+		return t.Format("-07:00:00")
+	case offset%3600 != 0:
+		return t.Format("-07:00")
+	}
+	return t.Format("-07")
+}
+
+func hour12(t time.Time) int {
+	if h := t.Hour() % 12; h != 0 {
+		return h
+	}
+	return 12
+}
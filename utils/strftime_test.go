@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// The expected texts are those of GNU date, which shares the strftime of
+// GNU ls, in the C locale.
+func TestStrftime(t *testing.T) {
+	morning := time.Date(2024, 3, 5, 7, 8, 9, 120000500, time.UTC)
+	afternoon := time.Date(2021, 1, 2, 15, 34, 5, 0, time.FixedZone("IST", 5*3600+1800))
+	tests := []struct{ format, morning, afternoon string }{
+		// Conversions.
+		{"%a", "Tue", "Sat"},
+		{"%A", "Tuesday", "Saturday"},
+		{"%b", "Mar", "Jan"},
+		{"%h", "Mar", "Jan"},
+		{"%B", "March", "January"},
+		{"%c", "Tue Mar  5 07:08:09 2024", "Sat Jan  2 15:34:05 2021"},
+		{"%C", "20", "20"},
+		{"%d", "05", "02"},
+		{"%D", "03/05/24", "01/02/21"},
+		{"%e", " 5", " 2"},
+		{"%F", "2024-03-05", "2021-01-02"},
+		{"%G", "2024", "2020"},
+		{"%g", "24", "20"},
+		{"%H", "07", "15"},
+		{"%I", "07", "03"},
+		{"%j", "065", "002"},
+		{"%k", " 7", "15"},
+		{"%l", " 7", " 3"},
+		{"%m", "03", "01"},
+		{"%M", "08", "34"},
+		{"%p", "AM", "PM"},
+		{"%P", "am", "pm"},
+		{"%r", "07:08:09 AM", "03:34:05 PM"},
+		{"%R", "07:08", "15:34"},
+		{"%s", "1709622489", "1609581845"},
+		{"%S", "09", "05"},
+		{"%T", "07:08:09", "15:34:05"},
+		{"%u", "2", "6"},
+		{"%U", "09", "00"},
+		{"%V", "10", "53"},
+		{"%w", "2", "6"},
+		{"%W", "10", "00"},
+		{"%x", "03/05/24", "01/02/21"},
+		{"%X", "07:08:09", "15:34:05"},
+		{"%y", "24", "21"},
+		{"%Y", "2024", "2021"},
+		{"%z", "+0000", "+0530"},
+		{"%:z", "+00:00", "+05:30"},
+		{"%Z", "UTC", "IST"},
+		{"%%", "%", "%"},
+		{"%q", "1", "1"},
+		{"%::z", "+00:00:00", "+05:30:00"},
+		{"%:::z", "+00", "+05:30"},
+		{"%Q", "%Q", "%Q"},
+		{"%5Q", "  %5Q", "  %5Q"},
+		{"%05Q", "0%05Q", "0%05Q"},
+		{"%-5Q", "%-5Q", "%-5Q"},
+		{"%:Y", "%:Y", "%:Y"},
+		{"%5:Q", "  %5:Q", "  %5:Q"},
+		{"%::::z", "%::::z", "%::::z"},
+
+		// Flags and widths.
+		{"%-d", "5", "2"},
+		{"%-e", "5", "2"},
+		{"%_m", " 3", " 1"},
+		{"%0e", "05", "02"},
+		{"%5Y", "02024", "02021"},
+		{"%_5Y", " 2024", " 2021"},
+		{"%-5Y", "2024", "2021"},
+		{"%05d", "00005", "00002"},
+		{"%-H", "7", "15"},
+		{"%-I", "7", "3"},
+		{"%-l", "7", "3"},
+		{"%-j", "65", "2"},
+		{"%^a", "TUE", "SAT"},
+		{"%^B", "MARCH", "JANUARY"},
+		{"%^c", "TUE MAR  5 07:08:09 2024", "SAT JAN  2 15:34:05 2021"},
+		{"%^p", "AM", "PM"},
+		{"%^P", "am", "pm"},
+
+		// `#` raises the case of the names and lowers that of %p and %Z.
+		{"%#a", "TUE", "SAT"},
+		{"%#A", "TUESDAY", "SATURDAY"},
+		{"%#b", "MAR", "JAN"},
+		{"%#B", "MARCH", "JANUARY"},
+		{"%#h", "MAR", "JAN"},
+		{"%#p", "am", "pm"},
+		{"%#P", "am", "pm"},
+		{"%#Z", "utc", "ist"},
+		{"%^#Z", "utc", "ist"},
+		{"%#c", "Tue Mar  5 07:08:09 2024", "Sat Jan  2 15:34:05 2021"},
+		{"%#d", "05", "02"},
+
+		// %N gives the digits of the fraction of a second.
+		{"%N", "120000500", "000000000"},
+		{"%3N", "120", "000"},
+		{"%6N", "120000", "000000"},
+		{"%1N", "1", "0"},
+		{"%03N", "120", "000"},
+		{"%^N", "120000500", "000000000"},
+		{"%10N", "1200005000", "0000000000"},
+		{"%12N", "120000500000", "000000000000"},
+		{"%-N", "120000500", "000000000"},
+		{"%-3N", "12", "0"},
+		{"%-1N", "1", "0"},
+		{"%-10N", "1200005", "0"},
+		{"%_N", "1200005  ", "0        "},
+		{"%_3N", "12 ", "0  "},
+		{"%_12N", "1200005     ", "0           "},
+	}
+	for _, tt := range tests {
+		if got := Strftime(tt.format, morning); got != tt.morning {
+			t.Errorf("Strftime(%q, %v) = %q, want %q", tt.format, morning, got, tt.morning)
+		}
+		if got := Strftime(tt.format, afternoon); got != tt.afternoon {
+			t.Errorf("Strftime(%q, %v) = %q, want %q", tt.format, afternoon, got, tt.afternoon)
+		}
+	}
+}
+
+func TestStrftimeText(t *testing.T) {
+	at := time.Date(2024, 3, 5, 7, 8, 9, 0, time.UTC)
+	tests := []struct{ format, want string }{
+		{"", ""},
+		{"plain", "plain"},
+		{"%Y-%m-%d %H:%M", "2024-03-05 07:08"},
+		{"a%nb%tc", "a\nb\tc"},
+		{"100%", "100%"},
+		{"%5", "   %5"},
+		{"%-", "%-"},
+	}
+	for _, tt := range tests {
+		if got := Strftime(tt.format, at); got != tt.want {
+			t.Errorf("Strftime(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
@@ -1,64 +1,64 @@
 package utils
 
 import (
-	"fmt"
+	"errors"
+	"os"
 	"strconv"
-	"strings"
 	"syscall"
+	"unsafe"
 )
 
+// defaultWidth is the output width used when it cannot be determined.
+const defaultWidth = 80
+
+// winsize mirrors the struct the TIOCGWINSZ ioctl fills in.
+type winsize struct {
+	Row    uint16
+	Col    uint16
+	Xpixel uint16
+	Ypixel uint16
+}
+
+// GetTerminalWidth asks the terminal driver for the number of columns of the
+// terminal stdout is connected to. It fails when stdout is not a terminal.
 func GetTerminalWidth() (int, error) {
-	// Execute "stty size" to get terminal dimensions
-	cmd := "/bin/stty"
-	args := []string{"stty", "size"}
+	var ws winsize
 
-	// Create a pipe to capture output
-	var pipe [2]int
-	err := syscall.Pipe(pipe[:])
-	if err != nil {
-		return 0, err
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL,
+		os.Stdout.Fd(),
+		uintptr(syscall.TIOCGWINSZ),
+		uintptr(unsafe.Pointer(&ws)),
+	)
+	if errno != 0 {
+		return 0, errno
 	}
-	read, write := pipe[0], pipe[1]
-
-	// Fork and execute the command
-	pid, err := syscall.ForkExec(cmd, args, &syscall.ProcAttr{
-		Files: []uintptr{0, uintptr(write), uintptr(write)}, // Redirect stdout and stderr to the pipe
-	})
-	if err != nil {
-		return 0, err
+	if ws.Col == 0 {
+		return 0, errors.New("terminal reports no width")
 	}
 
-	// Close the write end of the pipe
-	syscall.Close(write)
-
-	// Read output from the read end of the pipe
-	buf := make([]byte, 32)
-	n, err := syscall.Read(read, buf)
-	if err != nil {
-		return 0, err
-	}
+	return int(ws.Col), nil
+}
 
-	// Close the read end of the pipe
-	syscall.Close(read)
+// IsTerminal reports whether the file descriptor fd refers to a terminal.
+func IsTerminal(fd uintptr) bool {
+	var termios syscall.Termios
 
-	// Wait for the process to finish
-	_, err = syscall.Wait4(pid, nil, 0, nil)
-	if err != nil {
-		return 0, err
-	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL,
+		fd,
+		uintptr(ioctlGetTermios),
+		uintptr(unsafe.Pointer(&termios)),
+	)
+	return errno == 0
+}
 
-	// Parse output
-	output := strings.TrimSpace(string(buf[:n]))
-	parts := strings.Fields(output)
-	if len(parts) < 2 {
-		return 0, fmt.Errorf("unexpected output format: %s", output)
+// OutputWidth returns the width the listing is laid out for: the width of the
+// terminal, else the COLUMNS environment variable, else 80 columns.
+func OutputWidth() int {
+	if width, err := GetTerminalWidth(); err == nil {
+		return width
 	}
-
-	// Convert the second value (columns) to an integer
-	width, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return 0, err
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
 	}
-
-	return width, nil
+	return defaultWidth
 }
@@ -0,0 +1,8 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package utils
+
+import "syscall"
+
+// ioctlGetTermios is the ioctl request that reads the terminal attributes.
+const ioctlGetTermios = syscall.TIOCGETA
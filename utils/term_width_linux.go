@@ -0,0 +1,6 @@
+package utils
+
+import "syscall"
+
+// ioctlGetTermios is the ioctl request that reads the terminal attributes.
+const ioctlGetTermios = syscall.TCGETS
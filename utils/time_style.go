@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// TimeStyle holds the strftime formats (see Strftime) of the timestamps in
+// the long format, as set by --time-style, --full-time or the TIME_STYLE
+// environment variable, or says to print their age instead. The zero value
+// is the default style of ls.
+type TimeStyle struct {
+	Recent   string // format of the timestamps of the last six months
+	Old      string // format of the older timestamps and of those in the future
+	Relative bool   // print the age of the timestamps instead, e.g. "3h"
+	Verbose  bool   // with Relative, spell the age out, e.g. "3 hours ago"
+	Custom   bool   // set by +FORMAT, whose formats may be empty
+}
+
+// Now returns the current time, which decides the recent timestamps and the
+// ages of the relative style. Tests can replace it with a fixed clock.
+var Now = time.Now
+
+// Formats of the named styles.
+var (
+	localeStyle  = TimeStyle{Recent: "%b %e %H:%M", Old: "%b %e  %Y"}
+	fullISOStyle = TimeStyle{Recent: "%Y-%m-%d %H:%M:%S.%N %z", Old: "%Y-%m-%d %H:%M:%S.%N %z"}
+	longISOStyle = TimeStyle{Recent: "%Y-%m-%d %H:%M", Old: "%Y-%m-%d %H:%M"}
+	isoStyle     = TimeStyle{Recent: "%m-%d %H:%M", Old: "%Y-%m-%d "}
+)
+
+// Lengths of the average Gregorian year and month. A timestamp is printed
+// with the Old format from six months on, as in GNU ls.
+const (
+	year      = 31556952 * time.Second
+	month     = year / 12
+	day       = 24 * time.Hour
+	sixMonths = year / 2
+)
+
+// ageUnits are the units of the relative style, from the largest, with
+// their compact and verbose names.
+var ageUnits = []struct {
+	length           time.Duration
+	compact, verbose string
+}{
+	{year, "y", "year"},
+	{month, "mo", "month"},
+	{day, "d", "day"},
+	{time.Hour, "h", "hour"},
+	{time.Minute, "m", "minute"},
+	{time.Second, "s", "second"},
+}
+
+// ParseTimeStyle parses a time style: full-iso, long-iso, iso, locale,
+// relative ("3h"), relative-verbose ("3 hours ago"), or `+FORMAT` where
+// FORMAT is a strftime format, or two of them separated by a newline as in
+// GNU ls: first the one of the old timestamps, then the one of the recent
+// ones. A `posix-` prefix applies the style only outside the POSIX locale,
+// like GNU ls.
+func ParseTimeStyle(spec string) (TimeStyle, error) {
+	if style, ok := strings.CutPrefix(spec, "posix-"); ok {
+		if isPOSIXLocale() {
+			return localeStyle, nil
+		}
+		spec = style
+	}
+
+	if format, ok := strings.CutPrefix(spec, "+"); ok {
+		old, recent, twoFormats := strings.Cut(format, "\n")
+		if !twoFormats {
+			recent = old
+		} else if strings.Contains(recent, "\n") {
+			return TimeStyle{}, fmt.Errorf("invalid time style format '%s'", strings.ReplaceAll(format, "\n", `\n`))
+		}
+		return TimeStyle{Recent: recent, Old: old, Custom: true}, nil
+	}
+
+	switch spec {
+	case "full-iso":
+		return fullISOStyle, nil
+	case "long-iso":
+		return longISOStyle, nil
+	case "iso":
+		return isoStyle, nil
+	case "locale":
+		return localeStyle, nil
+	case "relative":
+		return TimeStyle{Relative: true}, nil
+	case "relative-verbose":
+		return TimeStyle{Relative: true, Verbose: true}, nil
+	}
+	return TimeStyle{}, fmt.Errorf("invalid argument '%s' for 'time style'", spec)
+}
+
+// timeStyleFromEnv returns the style set by the TIME_STYLE environment
+// variable. Invalid values are ignored.
+func timeStyleFromEnv() (TimeStyle, bool) {
+	if spec := os.Getenv("TIME_STYLE"); spec != "" {
+		if style, err := ParseTimeStyle(spec); err == nil {
+			return style, true
+		}
+	}
+	return TimeStyle{}, false
+}
+
+// IsDefault reports whether no style was chosen.
+func (style TimeStyle) IsDefault() bool {
+	return style == TimeStyle{}
+}
+
+// Format formats t for the long format. now is the time of the listing:
+// timestamps from the six months before it use the Recent format, and older
+// ones as well as those after now use the Old format. A timestamp after now
+// is compared with the clock once more first, in case the file was touched
+// since the listing started.
+func (style TimeStyle) Format(t, now time.Time) string {
+	if style.IsDefault() {
+		style = localeStyle
+	}
+	if t.After(now) {
+		now = Now()
+	}
+
+	if style.Relative {
+		return formatAge(now.Sub(t), style.Verbose)
+	}
+
+	if t.After(now.Add(-sixMonths)) && !t.After(now) {
+		return Strftime(style.Recent, t)
+	}
+	return Strftime(style.Old, t)
+}
+
+// formatAge formats the age of a timestamp in its largest whole unit: "3h"
+// or, when verbose, "3 hours ago". Timestamps in the future get "in 3h" and
+// "in 3 hours".
+func formatAge(age time.Duration, verbose bool) string {
+	future := age < 0
+	if future {
+		age = -age
+	}
+
+	unit := ageUnits[len(ageUnits)-1]
+	for _, u := range ageUnits {
+		if age >= u.length {
+			unit = u
+			break
+		}
+	}
+	count := int64(age / unit.length)
+
+	var text string
+	if verbose {
+		text = fmt.Sprintf("%d %s", count, unit.verbose)
+		if count != 1 {
+			text += "s"
+		}
+	} else {
+		text = fmt.Sprintf("%d%s", count, unit.compact)
+	}
+
+	switch {
+	case future:
+		return "in " + text
+	case verbose:
+		return text + " ago"
+	}
+	return text
+}
+
+// isPOSIXLocale reports whether the time locale is C or POSIX.
+func isPOSIXLocale() bool {
+	locale := ""
+	for _, name := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if locale = os.Getenv(name); locale != "" {
+			break
+		}
+	}
+	return locale == "" || locale == "C" || locale == "POSIX"
+}
@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// fixClock makes Now return now until the end of the test.
+func fixClock(t *testing.T, now time.Time) {
+	t.Helper()
+	saved := Now
+	Now = func() time.Time { return now }
+	t.Cleanup(func() { Now = saved })
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		age              time.Duration
+		compact, verbose string
+	}{
+		{0, "0s", "0 seconds ago"},
+		{time.Second, "1s", "1 second ago"},
+		{59 * time.Second, "59s", "59 seconds ago"},
+		{time.Minute, "1m", "1 minute ago"},
+		{time.Hour - time.Second, "59m", "59 minutes ago"},
+		{time.Hour, "1h", "1 hour ago"},
+		{day - time.Second, "23h", "23 hours ago"},
+		{day, "1d", "1 day ago"},
+		{month - time.Second, "30d", "30 days ago"},
+		{month, "1mo", "1 month ago"},
+		{year - time.Second, "11mo", "11 months ago"},
+		{year, "1y", "1 year ago"},
+		{25 * year, "25y", "25 years ago"},
+		{-time.Second, "in 1s", "in 1 second"},
+		{-3 * time.Hour, "in 3h", "in 3 hours"},
+		{-2 * year, "in 2y", "in 2 years"},
+	}
+	for _, tt := range tests {
+		if got := formatAge(tt.age, false); got != tt.compact {
+			t.Errorf("formatAge(%v, false) = %q, want %q", tt.age, got, tt.compact)
+		}
+		if got := formatAge(tt.age, true); got != tt.verbose {
+			t.Errorf("formatAge(%v, true) = %q, want %q", tt.age, got, tt.verbose)
+		}
+	}
+}
+
+func TestRelativeStyles(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	fixClock(t, now)
+
+	compact := TimeStyle{Relative: true}
+	verbose := TimeStyle{Relative: true, Verbose: true}
+	tests := []struct {
+		name                     string
+		t                        time.Time
+		wantCompact, wantVerbose string
+	}{
+		{"just now", now, "0s", "0 seconds ago"},
+		{"minutes", now.Add(-90 * time.Second), "1m", "1 minute ago"},
+		{"hours", now.Add(-5 * time.Hour), "5h", "5 hours ago"},
+		{"days", now.AddDate(0, 0, -3), "3d", "3 days ago"},
+		{"months", now.AddDate(0, -2, 0), "2mo", "2 months ago"},
+		{"years", now.AddDate(-3, 0, 0), "3y", "3 years ago"},
+		{"future", now.Add(2 * time.Minute), "in 2m", "in 2 minutes"},
+	}
+	for _, tt := range tests {
+		if got := compact.Format(tt.t, Now()); got != tt.wantCompact {
+			t.Errorf("%s: relative gives %q, want %q", tt.name, got, tt.wantCompact)
+		}
+		if got := verbose.Format(tt.t, Now()); got != tt.wantVerbose {
+			t.Errorf("%s: relative-verbose gives %q, want %q", tt.name, got, tt.wantVerbose)
+		}
+	}
+}
+
+// A timestamp after the time of the listing is measured against the clock
+// again, in case the file was touched since the listing started.
+func TestRelativeStyleRereadsTheClock(t *testing.T) {
+	start := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	fixClock(t, start.Add(10*time.Second))
+
+	touched := start.Add(5 * time.Second)
+	if got := (TimeStyle{Relative: true}).Format(touched, start); got != "5s" {
+		t.Errorf("got %q, want 5s", got)
+	}
+}
+
+func TestParseTimeStyle(t *testing.T) {
+	tests := []struct {
+		spec, locale string
+		want         TimeStyle
+	}{
+		{"full-iso", "C", fullISOStyle},
+		{"long-iso", "C", longISOStyle},
+		{"iso", "C", isoStyle},
+		{"locale", "C", localeStyle},
+		{"relative", "C", TimeStyle{Relative: true}},
+		{"relative-verbose", "C", TimeStyle{Relative: true, Verbose: true}},
+
+		// One format for all timestamps, or the old one then the recent one.
+		{"+%Y", "C", TimeStyle{Recent: "%Y", Old: "%Y", Custom: true}},
+		{"+%Y\n%H:%M", "C", TimeStyle{Recent: "%H:%M", Old: "%Y", Custom: true}},
+		{"+", "C", TimeStyle{Custom: true}},
+		{"+\n%H", "C", TimeStyle{Recent: "%H", Custom: true}},
+
+		// posix- styles are the locale style in the POSIX locale.
+		{"posix-iso", "C", localeStyle},
+		{"posix-iso", "POSIX", localeStyle},
+		{"posix-iso", "", localeStyle},
+		{"posix-iso", "C.UTF-8", isoStyle},
+		{"posix-full-iso", "en_US.UTF-8", fullISOStyle},
+		{"posix-+%Y", "C.UTF-8", TimeStyle{Recent: "%Y", Old: "%Y", Custom: true}},
+	}
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", tt.locale)
+		t.Setenv("LC_TIME", "")
+		t.Setenv("LANG", "")
+		got, err := ParseTimeStyle(tt.spec)
+		if err != nil || got != tt.want {
+			t.Errorf("LC_ALL=%s ParseTimeStyle(%q) = %+v, %v, want %+v", tt.locale, tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseTimeStyleErrors(t *testing.T) {
+	tests := []struct{ spec, want string }{
+		{"", "invalid argument '' for 'time style'"},
+		{"bogus", "invalid argument 'bogus' for 'time style'"},
+		{"ISO", "invalid argument 'ISO' for 'time style'"},
+		{"posix-bogus", "invalid argument 'bogus' for 'time style'"},
+		{"+%Y\n%m\n%d", `invalid time style format '%Y\n%m\n%d'`},
+	}
+	t.Setenv("LC_ALL", "C.UTF-8")
+	for _, tt := range tests {
+		_, err := ParseTimeStyle(tt.spec)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("ParseTimeStyle(%q) error = %v, want %q", tt.spec, err, tt.want)
+		}
+	}
+}
+
+// --time-style and --full-time override TIME_STYLE, the last of them wins,
+// and an invalid TIME_STYLE is ignored.
+func TestTimeStylePrecedence(t *testing.T) {
+	tests := []struct {
+		env  string
+		args []string
+		want TimeStyle
+	}{
+		{"", nil, TimeStyle{}},
+		{"iso", nil, isoStyle},
+		{"+%Y", nil, TimeStyle{Recent: "%Y", Old: "%Y", Custom: true}},
+		{"bogus", nil, TimeStyle{}},
+		{"iso", []string{"--time-style=long-iso"}, longISOStyle},
+		{"iso", []string{"--full-time"}, fullISOStyle},
+		{"", []string{"--time-style=iso", "--full-time"}, fullISOStyle},
+		{"", []string{"--full-time", "--time-style=iso"}, isoStyle},
+		{"long-iso", []string{"--time-style", "+"}, TimeStyle{Custom: true}},
+	}
+	t.Setenv("LC_ALL", "C.UTF-8")
+	for _, tt := range tests {
+		t.Setenv("TIME_STYLE", tt.env)
+		_, opts, err := ParseArgs(tt.args)
+		if err != nil {
+			t.Errorf("ParseArgs(%q): %v", tt.args, err)
+			continue
+		}
+		if got := ResolveOutput(opts).TimeStyle; got != tt.want {
+			t.Errorf("TIME_STYLE=%q ParseArgs(%q) time style = %+v, want %+v", tt.env, tt.args, got, tt.want)
+		}
+	}
+}
+
+// Timestamps of the last six months use the recent format; older ones and
+// those in the future use the old one.
+func TestTimeStyleFormat(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	fixClock(t, now)
+
+	style := TimeStyle{Recent: "recent", Old: "old", Custom: true}
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{now, "recent"},
+		{now.Add(-sixMonths + time.Second), "recent"},
+		{now.Add(-sixMonths), "old"},
+		{now.Add(time.Second), "old"},
+		{now.AddDate(-1, 0, 0), "old"},
+	}
+	for _, tt := range tests {
+		if got := style.Format(tt.t, now); got != tt.want {
+			t.Errorf("Format(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+
+	if got := (TimeStyle{}).Format(now.Add(-time.Hour), now); got != "Jun 15 11:00" {
+		t.Errorf("the default style gives %q, want %q", got, "Jun 15 11:00")
+	}
+	if got := (TimeStyle{Custom: true}).Format(now, now); got != "" {
+		t.Errorf("an empty format gives %q, want none", got)
+	}
+}